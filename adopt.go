@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+var shellNames = map[string]bool{"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true, "ksh": true}
+
+type inspectMsg struct {
+	id      string
+	command string
+}
+
+func inspectCmd(id string) tea.Cmd {
+	return timeoutCmd(func() tea.Msg {
+		command, _ := inspectSession(id)
+		return inspectMsg{id: id, command: command}
+	}, inspectMsg{id: id})
+}
+
+func inspectSession(id string) (command, cwd string) {
+	pid, err := strconv.ParseInt(id, 10, 32)
+	if err != nil {
+		return "", ""
+	}
+	screenProcess, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return "", ""
+	}
+	windows, _ := screenProcess.Children()
+	if len(windows) == 0 {
+		return "", ""
+	}
+	target := windows[0]
+	cwd, _ = target.Cwd()
+	if name, _ := target.Name(); shellNames[filepath.Base(name)] {
+		jobs, _ := target.Children()
+		if len(jobs) == 0 {
+			return "shell", cwd
+		}
+		target = jobs[0]
+		if jobCwd, err := target.Cwd(); err == nil {
+			cwd = jobCwd
+		}
+	}
+	command, _ = target.Cmdline()
+	return command, cwd
+}
+
+func adoptSession(session screenSession, name, command string) error {
+	if !session.foreign {
+		return fmt.Errorf("%s is already managed by spv", session.name)
+	}
+	if name == "" || strings.ContainsAny(name, ". \t") {
+		return fmt.Errorf("invalid session name %q", name)
+	}
+	if command == "" {
+		command = "shell"
+	}
+	_, cwd := inspectSession(session.id)
+
+	err := updateStore(func(store *Store) error {
+		if store.find(name) != nil {
+			return fmt.Errorf("a session named %s already exists", name)
+		}
+		if screenRunning(name) {
+			return fmt.Errorf("screen spv_%s is already running", name)
+		}
+		description := fmt.Sprintf("Adopted from screen session %s.", session.name)
+		if command == "shell" {
+			description = "A standard interactive shell session."
+		}
+		now := time.Now()
+		store.Sessions = append(store.Sessions, SessionEntry{
+			Name:        name,
+			Command:     command,
+			Description: description,
+			Cwd:         cwd,
+			CreatedAt:   now,
+			StartedAt:   session.started,
+		})
+		return nil
+	})
+	if err == nil {
+		// The screen is renamed only once the entry is committed, and the
+		// entry is dropped again if screen refuses.
+		output, renameErr := exec.Command("screen", "-S", session.screenName(), "-X", "sessionname", "spv_"+name).CombinedOutput()
+		if renameErr != nil {
+			err = fmt.Errorf("failed to rename %s: %v: %s", session.screenName(), renameErr, strings.TrimSpace(string(output)))
+			if removeErr := removeSessionEntry(name); removeErr != nil {
+				err = fmt.Errorf("%v; and failed to remove %s from the store: %v", err, name, removeErr)
+			}
+		}
+	}
+	if err == nil && os.MkdirAll(logsDir(), 0755) == nil {
+		target := session.id + ".spv_" + name
+		exec.Command("screen", "-S", target, "-X", "logfile", sessionLogFile(name)).Run()
+		exec.Command("screen", "-S", target, "-X", "log", "on").Run()
+	}
+	return err
+}
+
+// findForeignSession looks up a screen session not started by spv by its
+// name, with or without the pid prefix.
+func findForeignSession(target string) (screenSession, error) {
+	sessions, err := getScreens()
+	if err != nil {
+		return screenSession{}, err
+	}
+	for _, session := range sessions {
+		if session.foreign && (session.name == target || session.screenName() == target) {
+			return session, nil
+		}
+	}
+	return screenSession{}, fmt.Errorf("no foreign screen session named %s", target)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const hostTimeout = 5 * time.Second
+
+type hostResult struct {
+	sessions []SessionInfo
+	err      error
+	done     bool
+}
+
+type aggregateBackend struct {
+	local   sessionBackend
+	hosts   []HostProfile
+	mu      sync.Mutex
+	results map[string]hostResult
+	pending map[string]bool
+}
+
+func newAggregateBackend(local sessionBackend, hosts []HostProfile) *aggregateBackend {
+	return &aggregateBackend{
+		local:   local,
+		hosts:   hosts,
+		results: make(map[string]hostResult),
+		pending: make(map[string]bool),
+	}
+}
+
+func (b *aggregateBackend) poll(host HostProfile) {
+	ctx, cancel := context.WithTimeout(context.Background(), hostTimeout)
+	defer cancel()
+	sessions, err := host.list(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pending, host.Name)
+	if err != nil {
+		sessions = b.results[host.Name].sessions
+	}
+	b.results[host.Name] = hostResult{sessions: sessions, err: err, done: true}
+}
+
+func (b *aggregateBackend) snapshot() (snapshot, error) {
+	b.mu.Lock()
+	for _, host := range b.hosts {
+		if !b.pending[host.Name] {
+			b.pending[host.Name] = true
+			go b.poll(host)
+		}
+	}
+	b.mu.Unlock()
+
+	snap, err := b.local.snapshot()
+	if err != nil {
+		return snap, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	snap.Hosts = make(map[string]string)
+	for _, host := range b.hosts {
+		result := b.results[host.Name]
+		switch {
+		case !result.done:
+			snap.Hosts[host.Name] = "connecting"
+		case result.err != nil:
+			snap.Hosts[host.Name] = result.err.Error()
+		default:
+			snap.Hosts[host.Name] = ""
+		}
+		snap.Sessions = append(snap.Sessions, result.sessions...)
+	}
+	return snap, nil
+}
+
+func (b *aggregateBackend) remote(name string) (remoteBackend, error) {
+	host, ok := findHost(name)
+	if !ok {
+		return remoteBackend{}, fmt.Errorf("unknown host %q", name)
+	}
+	return remoteBackend{host: host}, nil
+}
+
+func (b *aggregateBackend) create(entry SessionEntry) error {
+	return b.local.create(entry)
+}
+
+func (b *aggregateBackend) kill(name string) error {
+	return b.local.kill(name)
+}
+
+func (b *aggregateBackend) send(name, text string) error {
+	return b.local.send(name, text)
+}
+
+func (b *aggregateBackend) restart(name string) error {
+	return b.local.restart(name)
+}
+
+func (b *aggregateBackend) setAutostart(name string, on bool) (string, error) {
+	return b.local.setAutostart(name, on)
+}
+
+func (b *aggregateBackend) rename(name, newName string) error {
+	return b.local.rename(name, newName)
+}
+
+func (b *aggregateBackend) adopt(session screenSession, name, command string) error {
+	return b.local.adopt(session, name, command)
+}
+
+func (b *aggregateBackend) attach(session screenSession) *exec.Cmd {
+	if session.host != "" {
+		if remote, err := b.remote(session.host); err == nil {
+			return remote.attach(session)
+		}
+	}
+	return b.local.attach(session)
+}
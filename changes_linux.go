@@ -0,0 +1,70 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM |
+	syscall.IN_CLOSE_WRITE | syscall.IN_ATTRIB
+
+func watchChanges(screenDir string, files []string) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	if _, err := syscall.InotifyAddWatch(fd, screenDir, watchMask); err != nil {
+		syscall.Close(fd)
+		return nil, &os.PathError{Op: "inotify_add_watch", Path: screenDir, Err: err}
+	}
+
+	names := make(map[int]map[string]bool)
+	for _, file := range files {
+		wd, err := syscall.InotifyAddWatch(fd, filepath.Dir(file), watchMask)
+		if err != nil {
+			continue
+		}
+		if names[wd] == nil {
+			names[wd] = make(map[string]bool)
+		}
+		names[wd][filepath.Base(file)] = true
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer syscall.Close(fd)
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := syscall.Read(fd, buf)
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil || n <= 0 {
+				close(changes)
+				return
+			}
+			changed := false
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				name := string(bytes.TrimRight(nameBytes, "\x00"))
+				if filter, ok := names[int(event.Wd)]; !ok || filter[name] {
+					changed = true
+				}
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+			}
+			if changed {
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
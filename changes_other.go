@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+import "fmt"
+
+func watchChanges(screenDir string, files []string) (<-chan struct{}, error) {
+	return nil, fmt.Errorf("watching for changes is not supported on this platform")
+}
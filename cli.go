@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print sessions as JSON")
+	all := fs.Bool("all", loadConfig().ShowForeign, "include screen sessions not started by spv")
+	fs.Parse(args)
+
+	snap, err := connectBackend().snapshot()
+	if err != nil {
+		return err
+	}
+	if !*all {
+		managed := []SessionInfo{}
+		for _, s := range snap.Sessions {
+			if !s.Foreign {
+				managed = append(managed, s)
+			}
+		}
+		snap.Sessions = managed
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snap.Sessions)
+	}
+	for _, s := range snap.Sessions {
+		autostart := ""
+		if s.Autostart {
+			autostart = " ●"
+		}
+		uptime := "-"
+		if !s.StartedAt.IsZero() {
+			uptime = formatDuration(time.Since(s.StartedAt))
+		}
+		fmt.Printf("%-8s %-24s %-9s %-12s %s\n", s.ID, s.Name+autostart, s.Status, uptime, s.Command)
+	}
+	return nil
+}
+
+func runCreate(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	command := fs.String("command", "shell", "command to run in the session")
+	description := fs.String("description", "", "description shown in the details pane")
+	cwd := fs.String("cwd", "", "directory to start in (default: current directory)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: spv create [--command cmd] [--description text] [--cwd dir] <name>")
+	}
+	name := fs.Arg(0)
+	if name == "" || strings.ContainsAny(name, ". \t") {
+		return fmt.Errorf("invalid session name %q", name)
+	}
+	if *cwd == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		*cwd = dir
+	}
+	if *description == "" && *command == "shell" {
+		*description = "A standard interactive shell session."
+	}
+	return connectBackend().create(SessionEntry{Name: name, Command: *command, Description: *description, Cwd: *cwd})
+}
+
+func runKill(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: spv kill <session>")
+	}
+	return connectBackend().kill(args[0])
+}
+
+func runSend(args []string) error {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	noNewline := fs.Bool("n", false, "do not append a newline")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		return fmt.Errorf("usage: spv send [-n] <session> <text>")
+	}
+	text := strings.Join(fs.Args()[1:], " ")
+	if !*noNewline {
+		text += "\n"
+	}
+	return connectBackend().send(fs.Arg(0), text)
+}
+
+func runRestart(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: spv restart <session>")
+	}
+	return connectBackend().restart(args[0])
+}
+
+func runAdopt(args []string) error {
+	fs := flag.NewFlagSet("adopt", flag.ExitOnError)
+	command := fs.String("command", "", "command the session runs (detected when empty)")
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("usage: spv adopt [--command cmd] <screen> [name]")
+	}
+	session, err := findForeignSession(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	name := session.name
+	if fs.NArg() == 2 {
+		name = fs.Arg(1)
+	}
+	if *command == "" {
+		*command, _ = inspectSession(session.id)
+	}
+	return connectBackend().adopt(session, name, *command)
+}
+
+func runDaemonCommand(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	metricsAddr := fs.String("metrics-listen", "", "serve Prometheus metrics on this address (e.g. :9183)")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: spv daemon [--metrics-listen addr]")
+	}
+	return runDaemon(*metricsAddr)
+}
+
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	session := fs.String("session", "", "only show events for this session")
+	limit := fs.Int("n", 50, "number of events to show (0 for all)")
+	asJSON := fs.Bool("json", false, "print events as JSON lines")
+	fs.Parse(args)
+
+	events, err := readHistory(*session, *limit)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if *asJSON {
+			data, _ := json.Marshal(event)
+			fmt.Println(string(data))
+		} else {
+			fmt.Println(event)
+		}
+	}
+	return nil
+}
+
+var commands = map[string]func(args []string) error{
+	"daemon":  runDaemonCommand,
+	"ls":      runList,
+	"create":  runCreate,
+	"kill":    runKill,
+	"send":    runSend,
+	"restart": runRestart,
+	"history": runHistory,
+	"adopt":   runAdopt,
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// colorProfile is what themes may use, which can be less than what the
+// terminal supports when NO_COLOR or --no-color is set.
+var colorProfile = termenv.TrueColor
+
+func setupColors(noColor bool) {
+	colorProfile = lipgloss.ColorProfile()
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		colorProfile = termenv.Ascii
+		// termenv turns NO_COLOR into plain ASCII, which would also drop
+		// the bold and reverse the monochrome theme relies on.
+		lipgloss.SetColorProfile(termenv.NewOutput(os.Stdout).ColorProfile())
+	}
+}
+
+// forProfile adapts a theme to the terminal. 256-color terminals get the
+// nearest palette entries from lipgloss; on 16 colors the panel backgrounds
+// are dropped and every accent is mapped to a plain ANSI hue, and without
+// color the monochrome theme takes over.
+func (t Theme) forProfile(profile termenv.Profile) Theme {
+	switch profile {
+	case termenv.Ascii:
+		return monoTheme
+	case termenv.ANSI:
+		if t.Monochrome {
+			return t
+		}
+		selectedBg := ansi16(t.SelectedBg)
+		selectedFg := lipgloss.Color("0")
+		switch selectedBg {
+		case "1", "4", "5", "8":
+			selectedFg = "15"
+		}
+		return Theme{
+			Border:         "8",
+			MutedText:      "8",
+			Accent:         ansi16(t.Accent),
+			SelectedBg:     selectedBg,
+			SelectedFg:     selectedFg,
+			StatusAttached: ansi16(t.StatusAttached),
+			StatusDetached: ansi16(t.StatusDetached),
+			ErrorBg:        "1",
+			ErrorFg:        "15",
+			LinkGit:        ansi16(t.LinkGit),
+			LinkBluesky:    ansi16(t.LinkBluesky),
+			LinkLinkedIn:   ansi16(t.LinkLinkedIn),
+			LinkSoundCloud: ansi16(t.LinkSoundCloud),
+		}
+	}
+	return t
+}
+
+// ansi16 maps a color to one of the 16 ANSI colors by hue rather than by
+// distance, so muted theme colors don't all collapse into black or white.
+func ansi16(color lipgloss.Color) lipgloss.Color {
+	value := string(color)
+	if n, err := strconv.Atoi(value); err == nil && n < 16 {
+		return color
+	}
+	if !hexColorPattern.MatchString(value) {
+		return color
+	}
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	rgb, _ := strconv.ParseUint(hex, 16, 32)
+	r := float64(rgb>>16&0xff) / 255
+	g := float64(rgb>>8&0xff) / 255
+	b := float64(rgb&0xff) / 255
+
+	high, low := max(r, g, b), min(r, g, b)
+	if high == 0 || (high-low)/high < 0.25 {
+		switch {
+		case high > 0.85:
+			return "15"
+		case high > 0.6:
+			return "7"
+		}
+		return "8"
+	}
+
+	var hue float64
+	switch high {
+	case r:
+		hue = 60 * (g - b) / (high - low)
+	case g:
+		hue = 60 * ((b-r)/(high-low) + 2)
+	default:
+		hue = 60 * ((r-g)/(high-low) + 4)
+	}
+	if hue < 0 {
+		hue += 360
+	}
+
+	var base int
+	switch {
+	case hue < 30 || hue >= 330:
+		base = 1
+	case hue < 75:
+		base = 3
+	case hue < 150:
+		base = 2
+	case hue < 210:
+		base = 6
+	case hue < 270:
+		base = 4
+	default:
+		base = 5
+	}
+	if high > 0.6 {
+		base += 8
+	}
+	return lipgloss.Color(strconv.Itoa(base))
+}
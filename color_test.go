@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestAnsi16(t *testing.T) {
+	tests := []struct {
+		color lipgloss.Color
+		want  lipgloss.Color
+	}{
+		{"#FFFFFF", "15"},
+		{"#CBD5E1", "15"},
+		{"#AAAAAA", "7"},
+		{"#333333", "8"},
+		{"#000000", "8"},
+		{"#800000", "1"},
+		{"#FF0000", "9"},
+		{"#F00", "9"},
+		{"#008000", "2"},
+		{"#00FF00", "10"},
+		{"#808000", "3"},
+		{"#FFFF00", "11"},
+		{"#000080", "4"},
+		{"#0000FF", "12"},
+		{"#800080", "5"},
+		{"#FF00FF", "13"},
+		{"#008080", "6"},
+		{"#00FFFF", "14"},
+		{"5", "5"},
+		{"15", "15"},
+		{"203", "203"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.color), func(t *testing.T) {
+			if got := ansi16(tt.color); got != tt.want {
+				t.Errorf("ansi16(%q) = %q, want %q", tt.color, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForProfile(t *testing.T) {
+	slate := themes["slate"]
+	tests := []struct {
+		name    string
+		theme   Theme
+		profile termenv.Profile
+		want    Theme
+	}{
+		{"true color unchanged", slate, termenv.TrueColor, slate},
+		{"256 colors unchanged", slate, termenv.ANSI256, slate},
+		{"no color is monochrome", slate, termenv.Ascii, monoTheme},
+		{"monochrome on 16 colors", monoTheme, termenv.ANSI, monoTheme},
+		{
+			"16 colors",
+			Theme{Accent: "#06B6D4", SelectedBg: "#1E3A8A", StatusAttached: "#22C55E", StatusDetached: "#F59E0B", HeaderBg: "#1E293B"},
+			termenv.ANSI,
+			Theme{
+				Border: "8", MutedText: "8", Accent: "14", SelectedBg: "4", SelectedFg: "15",
+				StatusAttached: "10", StatusDetached: "11", ErrorBg: "1", ErrorFg: "15",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.theme.forProfile(tt.profile); got != tt.want {
+				t.Errorf("forProfile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtendsMono(t *testing.T) {
+	builtin := map[string]Theme{"mono": monoTheme, "slate": themes["slate"]}
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"alias", `{"extends": "mono"}`, ""},
+		{"with colors", `{"extends": "mono", "accent": "#fff"}`, "mono has no colors to override"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, err := parseThemeFile([]byte(tt.data), ".json", builtin)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseThemeFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || theme != monoTheme {
+				t.Errorf("parseThemeFile() = %+v, %v, want the monochrome theme", theme, err)
+			}
+		})
+	}
+}
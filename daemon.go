@@ -0,0 +1,519 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type SessionInfo struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Status        string    `json:"status"`
+	Autostart     bool      `json:"autostart"`
+	Command       string    `json:"command"`
+	Description   string    `json:"description"`
+	DependsOn     []string  `json:"depends_on,omitempty"`
+	BootStatus    string    `json:"boot_status,omitempty"`
+	BootDetail    string    `json:"boot_detail,omitempty"`
+	StartedAt     time.Time `json:"started_at,omitzero"`
+	UptimeSeconds int64     `json:"uptime_seconds,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitzero"`
+	LastSeen      time.Time `json:"last_seen,omitzero"`
+	ExitedAt      time.Time `json:"exited_at,omitzero"`
+	ExitCode      string    `json:"exit_code,omitempty"`
+	ExitCodeAt    time.Time `json:"exit_code_at,omitzero"`
+	Host          string    `json:"host,omitempty"`
+	Foreign       bool      `json:"foreign,omitempty"`
+}
+
+type snapshot struct {
+	CPU      float64           `json:"cpu"`
+	Mem      float64           `json:"mem"`
+	Sessions []SessionInfo     `json:"sessions"`
+	Hosts    map[string]string `json:"hosts,omitempty"`
+}
+
+func newSnapshot(sessions []screenSession, cpuUsage, memUsage float64) snapshot {
+	snap := snapshot{CPU: cpuUsage, Mem: memUsage, Sessions: []SessionInfo{}}
+	for _, s := range sessions {
+		info := SessionInfo{
+			ID:          s.id,
+			Name:        s.name,
+			Status:      s.status,
+			Autostart:   s.autostart,
+			Command:     s.command,
+			Description: s.description,
+			DependsOn:   s.dependsOn,
+			BootStatus:  s.bootStatus,
+			BootDetail:  s.bootDetail,
+			StartedAt:   s.started,
+			CreatedAt:   s.createdAt,
+			LastSeen:    s.lastSeen,
+			ExitedAt:    s.exitedAt,
+			ExitCode:    s.exitCode,
+			ExitCodeAt:  s.exitCodeAt,
+			Host:        s.host,
+			Foreign:     s.foreign,
+		}
+		if !s.started.IsZero() {
+			info.UptimeSeconds = int64(time.Since(s.started).Seconds())
+		}
+		snap.Sessions = append(snap.Sessions, info)
+	}
+	return snap
+}
+
+func (snap snapshot) screenSessions() []screenSession {
+	var sessions []screenSession
+	for _, s := range snap.Sessions {
+		sessions = append(sessions, screenSession{
+			id:          s.ID,
+			name:        s.Name,
+			status:      s.Status,
+			autostart:   s.Autostart,
+			command:     s.Command,
+			description: s.Description,
+			dependsOn:   s.DependsOn,
+			bootStatus:  s.BootStatus,
+			bootDetail:  s.BootDetail,
+			started:     s.StartedAt,
+			createdAt:   s.CreatedAt,
+			lastSeen:    s.LastSeen,
+			exitedAt:    s.ExitedAt,
+			exitCode:    s.ExitCode,
+			exitCodeAt:  s.ExitCodeAt,
+			host:        s.Host,
+			foreign:     s.Foreign,
+		})
+	}
+	return sessions
+}
+
+type sessionBackend interface {
+	snapshot() (snapshot, error)
+	create(entry SessionEntry) error
+	kill(name string) error
+	send(name, text string) error
+	restart(name string) error
+	setAutostart(name string, on bool) (string, error)
+	rename(name, newName string) error
+	adopt(session screenSession, name, command string) error
+	attach(session screenSession) *exec.Cmd
+}
+
+type localBackend struct {
+	source string
+}
+
+func (localBackend) snapshot() (snapshot, error) {
+	sessions, err := getScreens()
+	if err != nil {
+		return snapshot{Sessions: []SessionInfo{}}, err
+	}
+	cpuUsage, memUsage := getSystemStats()
+	return newSnapshot(sessions, cpuUsage, memUsage), nil
+}
+
+func (b localBackend) create(entry SessionEntry) error {
+	err := createScreenSession(entry.Name, entry.Command, entry.Description, entry.Cwd)
+	recordEvent(b.source, "create", entry.Name, err)
+	return err
+}
+
+func (b localBackend) kill(name string) error {
+	err := killSession(name)
+	recordEvent(b.source, "kill", name, err)
+	return err
+}
+
+func (b localBackend) send(name, text string) error {
+	err := sendToSession(name, text)
+	recordEvent(b.source, "send", name, err)
+	return err
+}
+
+func (b localBackend) restart(name string) error {
+	err := restartSession(name)
+	recordEvent(b.source, "restart", name, err)
+	return err
+}
+
+func (b localBackend) setAutostart(name string, on bool) (string, error) {
+	summary, err := setSessionAutostart(name, on)
+	action := "autostart-off"
+	if on {
+		action = "autostart-on"
+	}
+	recordEvent(b.source, action, name, err)
+	return summary, err
+}
+
+func (b localBackend) rename(name, newName string) error {
+	err := renameSession(name, newName)
+	recordEvent(b.source, "rename", name, err)
+	return err
+}
+
+func (b localBackend) adopt(session screenSession, name, command string) error {
+	err := adoptSession(session, name, command)
+	recordEvent(b.source, "adopt", name, err)
+	return err
+}
+
+func (localBackend) attach(session screenSession) *exec.Cmd {
+	return exec.Command("screen", "-r", session.screenName())
+}
+
+func socketPath() string {
+	return filepath.Join(runtimeDir, "spv.sock")
+}
+
+type daemonClient struct {
+	http *http.Client
+}
+
+func newDaemonClient() *daemonClient {
+	return &daemonClient{http: &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath())
+			},
+		},
+	}}
+}
+
+func connectBackend() sessionBackend {
+	client := newDaemonClient()
+	if _, err := client.snapshot(); err != nil {
+		return localBackend{source: clientName}
+	}
+	return client
+}
+
+func (c *daemonClient) do(method, path string, body, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, "http://spv"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Spv-Client", clientName)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s", apiErr.Error)
+		}
+		return fmt.Errorf("daemon returned %s", resp.Status)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (c *daemonClient) snapshot() (snapshot, error) {
+	var snap snapshot
+	err := c.do("GET", "/v1/sessions", nil, &snap)
+	return snap, err
+}
+
+func (c *daemonClient) create(entry SessionEntry) error {
+	return c.do("POST", "/v1/sessions", entry, nil)
+}
+
+func (c *daemonClient) kill(name string) error {
+	return c.do("DELETE", "/v1/sessions/"+url.PathEscape(name), nil, nil)
+}
+
+func (c *daemonClient) send(name, text string) error {
+	return c.do("POST", "/v1/sessions/"+url.PathEscape(name)+"/send", map[string]string{"text": text}, nil)
+}
+
+func (c *daemonClient) restart(name string) error {
+	return c.do("POST", "/v1/sessions/"+url.PathEscape(name)+"/restart", nil, nil)
+}
+
+func (c *daemonClient) setAutostart(name string, on bool) (string, error) {
+	var out struct {
+		Backend string `json:"backend"`
+	}
+	err := c.do("PUT", "/v1/sessions/"+url.PathEscape(name)+"/autostart", map[string]bool{"autostart": on}, &out)
+	return out.Backend, err
+}
+
+func (c *daemonClient) rename(name, newName string) error {
+	return c.do("POST", "/v1/sessions/"+url.PathEscape(name)+"/rename", map[string]string{"name": newName}, nil)
+}
+
+func (c *daemonClient) adopt(session screenSession, name, command string) error {
+	return c.do("POST", "/v1/sessions/"+url.PathEscape(session.name)+"/adopt", map[string]string{"name": name, "command": command}, nil)
+}
+
+func (c *daemonClient) attach(session screenSession) *exec.Cmd {
+	return localBackend{}.attach(session)
+}
+
+type daemon struct {
+	mu        sync.RWMutex
+	refreshMu sync.Mutex
+	current   snapshot
+	exits     *exitDetector
+}
+
+func (d *daemon) refresh() {
+	d.refreshMu.Lock()
+	defer d.refreshMu.Unlock()
+	rotateSessionLogs()
+	snap, err := localBackend{}.snapshot()
+	if err != nil {
+		return
+	}
+	d.exits.observe(snap.screenSessions())
+	d.mu.Lock()
+	d.current = snap
+	d.mu.Unlock()
+}
+
+func (d *daemon) loop(ctx context.Context) {
+	changes, interval := watchSessions()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil
+				ticker.Reset(loadConfig().pollInterval())
+			}
+			d.refresh()
+		case <-ticker.C:
+			d.refresh()
+		}
+	}
+}
+
+func requestBackend(r *http.Request) localBackend {
+	source := "daemon"
+	if client := r.Header.Get("X-Spv-Client"); client != "" {
+		source += "/" + client
+	}
+	return localBackend{source: source}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (d *daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", d.metricsHandler)
+	mux.HandleFunc("GET /v1/sessions", func(w http.ResponseWriter, r *http.Request) {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+		writeJSON(w, http.StatusOK, d.current)
+	})
+	mux.HandleFunc("POST /v1/sessions", func(w http.ResponseWriter, r *http.Request) {
+		var entry SessionEntry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if entry.Cwd == "" {
+			entry.Cwd, _ = os.UserHomeDir()
+		}
+		if err := requestBackend(r).create(entry); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		d.refresh()
+		writeJSON(w, http.StatusCreated, map[string]string{"status": "created"})
+	})
+	mux.HandleFunc("DELETE /v1/sessions/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if err := requestBackend(r).kill(r.PathValue("name")); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		d.refresh()
+		writeJSON(w, http.StatusOK, map[string]string{"status": "killed"})
+	})
+	mux.HandleFunc("POST /v1/sessions/{name}/send", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := requestBackend(r).send(r.PathValue("name"), body.Text); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "sent"})
+	})
+	mux.HandleFunc("POST /v1/sessions/{name}/restart", func(w http.ResponseWriter, r *http.Request) {
+		if err := requestBackend(r).restart(r.PathValue("name")); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		d.refresh()
+		writeJSON(w, http.StatusOK, map[string]string{"status": "restarted"})
+	})
+	mux.HandleFunc("PUT /v1/sessions/{name}/autostart", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Autostart bool `json:"autostart"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		summary, err := requestBackend(r).setAutostart(r.PathValue("name"), body.Autostart)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		d.refresh()
+		writeJSON(w, http.StatusOK, map[string]string{"status": "updated", "backend": summary})
+	})
+	mux.HandleFunc("POST /v1/sessions/{name}/rename", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := requestBackend(r).rename(r.PathValue("name"), body.Name); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		d.refresh()
+		writeJSON(w, http.StatusOK, map[string]string{"status": "renamed"})
+	})
+	mux.HandleFunc("POST /v1/sessions/{name}/adopt", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Name    string `json:"name"`
+			Command string `json:"command"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		session, err := findForeignSession(r.PathValue("name"))
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		if err := requestBackend(r).adopt(session, body.Name, body.Command); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		d.refresh()
+		writeJSON(w, http.StatusOK, map[string]string{"status": "adopted"})
+	})
+	return mux
+}
+
+func (d *daemon) serveMetrics(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", d.metricsHandler)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics on %s: %v", addr, err)
+	}
+	server := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	fmt.Printf("spv metrics on http://%s/metrics\n", listener.Addr())
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("spv metrics server stopped: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func runDaemon(metricsAddr string) error {
+	if _, err := newDaemonClient().snapshot(); err == nil {
+		return fmt.Errorf("spv daemon is already running on %s", socketPath())
+	}
+	os.Remove(socketPath())
+
+	listener, err := net.Listen("unix", socketPath())
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", socketPath(), err)
+	}
+	defer os.Remove(socketPath())
+	if err := os.Chmod(socketPath(), 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	d := &daemon{exits: newExitDetector("daemon")}
+	d.refresh()
+	go d.loop(ctx)
+
+	if metricsAddr == "" {
+		metricsAddr = loadConfig().MetricsListen
+	}
+	if metricsAddr != "" {
+		if err := d.serveMetrics(ctx, metricsAddr); err != nil {
+			return err
+		}
+	}
+
+	server := &http.Server{Handler: d.handler()}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	fmt.Printf("spv daemon listening on %s\n", socketPath())
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
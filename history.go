@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var clientName = "tui"
+
+type historyEvent struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Source  string    `json:"source"`
+	Action  string    `json:"action"`
+	Session string    `json:"session"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func historyFile() string {
+	return filepath.Join(stateDir, "history.jsonl")
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func recordEvent(source, action, session string, err error) {
+	event := historyEvent{
+		Time:    time.Now(),
+		User:    currentUser(),
+		Source:  source,
+		Action:  action,
+		Session: session,
+		Outcome: "ok",
+	}
+	if err != nil {
+		event.Outcome = "error"
+		event.Error = err.Error()
+	}
+	data, marshalErr := json.Marshal(event)
+	if marshalErr != nil {
+		return
+	}
+	f, openErr := os.OpenFile(historyFile(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if openErr != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+func readHistory(session string, limit int) ([]historyEvent, error) {
+	f, err := os.Open(historyFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []historyEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event historyEvent
+		if json.Unmarshal(scanner.Bytes(), &event) != nil {
+			continue
+		}
+		if session != "" && event.Session != session {
+			continue
+		}
+		events = append(events, event)
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events, scanner.Err()
+}
+
+func (e historyEvent) String() string {
+	line := fmt.Sprintf("%s  %-14s %-16s %s (%s) %s",
+		e.Time.Format("2006-01-02 15:04:05"), e.Action, e.Session, e.User, e.Source, e.Outcome)
+	if e.Error != "" {
+		line += ": " + e.Error
+	}
+	return line
+}
+
+type historyMsg struct {
+	events []historyEvent
+	err    error
+}
+
+func (m *model) refreshHistoryView() tea.Cmd {
+	if m.historyLoading {
+		return nil
+	}
+	m.historyLoading = true
+	return timeoutCmd(func() tea.Msg {
+		events, err := readHistory("", 1000)
+		return historyMsg{events: events, err: err}
+	}, historyMsg{err: fmt.Errorf("reading history timed out")})
+}
+
+func (m *model) applyHistory(msg historyMsg) {
+	m.historyLoading = false
+	m.historyEvents, m.historyErr = msg.events, msg.err
+	m.showHistory()
+}
+
+func (m *model) showHistory() {
+	width := m.width - 6
+	height := m.height - 8
+	if width < 10 {
+		width = 10
+	}
+	if height < 3 {
+		height = 3
+	}
+	m.historyViewport.Width = width
+	m.historyViewport.Height = height
+
+	events, err := m.historyEvents, m.historyErr
+	var lines []string
+	switch {
+	case err != nil:
+		lines = append(lines, errorTextStyle.Render(err.Error()))
+	case len(events) == 0 && m.historyLoading:
+		lines = append(lines, mutedTextStyle.Render("Loading history..."))
+	case len(events) == 0:
+		lines = append(lines, mutedTextStyle.Render("No recorded actions yet."))
+	}
+	for _, event := range events {
+		if event.Outcome == "error" {
+			lines = append(lines, statusDetachedStyle.Render(event.String()))
+		} else {
+			lines = append(lines, normalTextStyle.Render(event.String()))
+		}
+	}
+	following := m.historyViewport.AtBottom()
+	m.historyViewport.SetContent(strings.Join(lines, "\n"))
+	if following {
+		m.historyViewport.GotoBottom()
+	}
+}
+
+func (m model) historyView() string {
+	box := contentStyle.Copy().Width(m.width - 2).Render(
+		accentStyle.Render("history") + "\n\n" +
+			m.historyViewport.View() + "\n\n" +
+			mutedTextStyle.Render(m.keys.scrollHints()),
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
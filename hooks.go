@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const hookTimeout = 30 * time.Second
+
+type Hook struct {
+	Events  []string `json:"events,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Command string   `json:"command,omitempty"`
+}
+
+type hookEvent struct {
+	Event       string    `json:"event"`
+	Session     string    `json:"session"`
+	Command     string    `json:"command,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Host        string    `json:"host"`
+	Time        time.Time `json:"time"`
+}
+
+func (h Hook) wants(event string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func runHook(hook Hook, event hookEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	if hook.URL != "" {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", hook.URL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("POST %s: %v", hook.URL, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("POST %s: %s", hook.URL, resp.Status)
+		}
+	}
+
+	if hook.Command != "" {
+		cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command)
+		cmd.Env = append(os.Environ(),
+			"SPV_EVENT="+event.Event,
+			"SPV_SESSION="+event.Session,
+			"SPV_COMMAND="+event.Command,
+			"SPV_DESCRIPTION="+event.Description,
+			"SPV_HOST="+event.Host,
+			"SPV_TIME="+event.Time.Format(time.RFC3339),
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %v: %s", hook.Command, err, bytes.TrimSpace(output))
+		}
+	}
+	return nil
+}
+
+func logHookError(event hookEvent, err error) {
+	f, openErr := os.OpenFile(filepath.Join(stateDir, "hooks.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if openErr != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %s %s: %v\n", time.Now().Format(time.RFC3339), event.Event, event.Session, err)
+}
+
+var pendingHooks sync.WaitGroup
+
+func fireHooks(event string, entry SessionEntry) {
+	hooks := loadConfig().Hooks
+	if len(hooks) == 0 {
+		return
+	}
+	host, _ := os.Hostname()
+	payload := hookEvent{
+		Event:       event,
+		Session:     entry.Name,
+		Command:     entry.Command,
+		Description: entry.Description,
+		Host:        host,
+		Time:        time.Now(),
+	}
+	for _, hook := range hooks {
+		if !hook.wants(event) {
+			continue
+		}
+		pendingHooks.Add(1)
+		go func(hook Hook) {
+			defer pendingHooks.Done()
+			if err := runHook(hook, payload); err != nil {
+				logHookError(payload, err)
+			}
+		}(hook)
+	}
+}
+
+// Expected exits are marker files in the runtime directory, so a kill from
+// the TUI or the CLI is not reported as a crash by the daemon's detector.
+// Markers are never removed on read; they expire after expectedExitWindow.
+const expectedExitWindow = time.Minute
+
+func expectedExitFile(name string) string {
+	return filepath.Join(runtimeDir, "expected", name)
+}
+
+func expectExit(name string) {
+	path := expectedExitFile(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	now := time.Now()
+	if os.WriteFile(path, nil, 0600) == nil {
+		os.Chtimes(path, now, now)
+	}
+	pruneExpectedExits()
+}
+
+func exitWasExpected(name string) bool {
+	info, err := os.Stat(expectedExitFile(name))
+	return err == nil && time.Since(info.ModTime()) < expectedExitWindow
+}
+
+func pruneExpectedExits() {
+	dir := filepath.Dir(expectedExitFile(""))
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) >= expectedExitWindow {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+// exitOwnerFile is locked by the one process that reports sessions which
+// vanished on their own. The daemon and every TUI run a detector, and only
+// the lock holder acts, so a crash is reported once.
+func exitOwnerFile() string {
+	return filepath.Join(runtimeDir, "exits")
+}
+
+type exitDetector struct {
+	source   string
+	owner    *os.File
+	owned    bool
+	running  map[string]bool
+	lastSeen time.Time
+}
+
+func newExitDetector(source string) *exitDetector {
+	return &exitDetector{source: source}
+}
+
+func (d *exitDetector) own() bool {
+	if !d.owned {
+		d.owner, d.owned = tryFileLock(exitOwnerFile())
+	}
+	return d.owned
+}
+
+// release gives up ownership, so a TUI that switched to the daemon lets the
+// daemon take over exit detection.
+func (d *exitDetector) release() {
+	if d.owner != nil {
+		d.owner.Close()
+	}
+	d.owner, d.owned, d.running = nil, false, nil
+}
+
+func (d *exitDetector) observe(sessions []screenSession) {
+	// Without the lock, forget what was running: the owner reports those
+	// exits, and taking over later starts from a fresh list.
+	if !d.own() {
+		d.running = nil
+		return
+	}
+	now := time.Now()
+	current := make(map[string]bool)
+	for _, s := range sessions {
+		if s.status != "exited" && !s.foreign {
+			current[s.name] = true
+		}
+	}
+	if d.running != nil {
+		var vanished []string
+		for name := range d.running {
+			if !current[name] && !exitWasExpected(name) {
+				vanished = append(vanished, name)
+			}
+		}
+		if len(vanished) > 0 {
+			lastSeen := d.lastSeen
+			var store Store
+			updateStore(func(s *Store) error {
+				for _, name := range vanished {
+					if entry := s.find(name); entry != nil {
+						entry.LastSeen = lastSeen
+						entry.ExitedAt = now
+					}
+				}
+				store = *s
+				return nil
+			})
+			for _, name := range vanished {
+				entry := SessionEntry{Name: name}
+				if found := store.find(name); found != nil {
+					entry = *found
+				}
+				recordEvent(d.source, "disappeared", name, nil)
+				fireHooks("disappeared", entry)
+			}
+		}
+	}
+	d.running = current
+	d.lastSeen = now
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestExitDetectorsShareOneMarker(t *testing.T) {
+	if err := setupPaths(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	daemon, tui := newExitDetector("daemon"), newExitDetector("tui")
+	defer daemon.release()
+	defer tui.release()
+	observe := func(sessions ...screenSession) {
+		daemon.observe(sessions)
+		tui.observe(sessions)
+	}
+
+	observe(screenSession{name: "killed"}, screenSession{name: "crashed"})
+	expectExit("killed")
+	observe()
+	observe()
+
+	disappeared := map[string]int{}
+	events, err := readHistory("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		if event.Action == "disappeared" {
+			disappeared[event.Session]++
+			if event.Source != "daemon" {
+				t.Errorf("%s reported by %s, want daemon", event.Session, event.Source)
+			}
+		}
+	}
+	if disappeared["killed"] != 0 {
+		t.Errorf("expected exit reported %d times", disappeared["killed"])
+	}
+	if disappeared["crashed"] != 1 {
+		t.Errorf("crash reported %d times, want 1", disappeared["crashed"])
+	}
+	if !exitWasExpected("killed") {
+		t.Error("marker was consumed on read")
+	}
+
+	daemon.release()
+	observe(screenSession{name: "killed"})
+	observe()
+	events, _ = readHistory("killed", 0)
+	for _, event := range events {
+		if event.Action == "disappeared" {
+			t.Errorf("marker ignored after %s took over", event.Source)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type keyMap struct {
+	Up          key.Binding
+	Down        key.Binding
+	Top         key.Binding
+	Bottom      key.Binding
+	Attach      key.Binding
+	Add         key.Binding
+	Kill        key.Binding
+	Autostart   key.Binding
+	Logs        key.Binding
+	Adopt       key.Binding
+	Foreign     key.Binding
+	Filter      key.Binding
+	ClearFilter key.Binding
+	Sort        key.Binding
+	Sidebar     key.Binding
+	Hosts       key.Binding
+	Refresh     key.Binding
+	History     key.Binding
+	Notices     key.Binding
+	Dismiss     key.Binding
+	Help        key.Binding
+	About       key.Binding
+	Quit        key.Binding
+	Restart     key.Binding
+	Rename      key.Binding
+	Palette     key.Binding
+	Theme       key.Binding
+
+	Back       key.Binding
+	PaneUp     key.Binding
+	PaneDown   key.Binding
+	PaneTop    key.Binding
+	PaneBottom key.Binding
+	Confirm    key.Binding
+	Search     key.Binding
+	NextMatch  key.Binding
+	PrevMatch  key.Binding
+}
+
+type keyAction struct {
+	name    string
+	desc    string
+	title   string
+	binding *key.Binding
+}
+
+func (k *keyMap) actions() []keyAction {
+	return []keyAction{
+		{"up", "move up", "select previous session", &k.Up},
+		{"down", "move down", "select next session", &k.Down},
+		{"top", "first session", "select first session", &k.Top},
+		{"bottom", "last session", "select last session", &k.Bottom},
+		{"attach", "attach", "attach to session", &k.Attach},
+		{"add", "add", "add session", &k.Add},
+		{"kill", "kill", "kill session", &k.Kill},
+		{"autostart", "toggle autostart", "toggle autostart", &k.Autostart},
+		{"logs", "logs", "open logs", &k.Logs},
+		{"adopt", "adopt session", "adopt foreign session", &k.Adopt},
+		{"foreign", "show foreign", "show/hide foreign sessions", &k.Foreign},
+		{"filter", "filter", "filter sessions", &k.Filter},
+		{"clear_filter", "clear filter", "clear filter", &k.ClearFilter},
+		{"sort", "sort", "cycle sort order", &k.Sort},
+		{"sidebar", "sidebar", "toggle sidebar", &k.Sidebar},
+		{"hosts", "hosts", "pick host", &k.Hosts},
+		{"refresh", "refresh", "refresh sessions", &k.Refresh},
+		{"history", "history", "show history", &k.History},
+		{"notices", "notices", "show notifications", &k.Notices},
+		{"dismiss", "dismiss notice", "dismiss notification", &k.Dismiss},
+		{"help", "help", "show keybindings", &k.Help},
+		{"about", "about", "about spv", &k.About},
+		{"quit", "quit", "quit", &k.Quit},
+		{"restart", "restart", "restart session", &k.Restart},
+		{"rename", "rename", "rename session", &k.Rename},
+		{"palette", "commands", "command palette", &k.Palette},
+		{"theme", "theme", "pick theme", &k.Theme},
+	}
+}
+
+// paneActions are the keys used inside the log, history and notification
+// panes, the pickers and the help screen. They only clash with each other,
+// not with the session list.
+func (k *keyMap) paneActions() []keyAction {
+	return []keyAction{
+		{"back", "back", "close pane", &k.Back},
+		{"pane_up", "up", "move up in pane", &k.PaneUp},
+		{"pane_down", "down", "move down in pane", &k.PaneDown},
+		{"pane_top", "top", "jump to top of pane", &k.PaneTop},
+		{"pane_bottom", "bottom", "jump to bottom of pane", &k.PaneBottom},
+		{"confirm", "choose", "confirm choice", &k.Confirm},
+		{"search", "search", "search log", &k.Search},
+		{"next_match", "next match", "next log match", &k.NextMatch},
+		{"prev_match", "prev match", "previous log match", &k.PrevMatch},
+	}
+}
+
+var keyPresets = map[string]map[string][]string{
+	"default": {
+		"up": {"up"}, "down": {"down"}, "top": {"home"}, "bottom": {"end"},
+		"attach": {"enter"}, "add": {"a"}, "kill": {"k"}, "autostart": {"t"}, "logs": {"l"},
+		"adopt": {"A"}, "foreign": {"F"}, "filter": {"/"}, "clear_filter": {"esc"}, "sort": {"s"},
+		"sidebar": {"b"}, "hosts": {"H"}, "refresh": {"r"}, "history": {"h"}, "notices": {"N"},
+		"dismiss": {"x"}, "help": {"?"}, "about": {"i"}, "quit": {"q"},
+		"restart": {"R"}, "palette": {":", "ctrl+p"}, "theme": {"T"},
+
+		"back": {"esc", "q"}, "pane_up": {"up", "k"}, "pane_down": {"down", "j"},
+		"pane_top": {"g", "home"}, "pane_bottom": {"G", "end"}, "confirm": {"enter"},
+		"search": {"/"}, "next_match": {"n"}, "prev_match": {"N"},
+	},
+	"vim": {
+		"up": {"up", "k"}, "down": {"down", "j"}, "top": {"g", "home"}, "bottom": {"G", "end"},
+		"attach": {"enter"}, "add": {"o"}, "kill": {"d"}, "autostart": {"t"}, "logs": {"l"},
+		"adopt": {"A"}, "foreign": {"F"}, "filter": {"/"}, "clear_filter": {"esc"}, "sort": {"s"},
+		"sidebar": {"b"}, "hosts": {"H"}, "refresh": {"r"}, "history": {"h"}, "notices": {"N"},
+		"dismiss": {"x"}, "help": {"?"}, "about": {"i"}, "quit": {"q"},
+		"restart": {"R"}, "palette": {":"}, "theme": {"T"},
+
+		"back": {"esc", "q"}, "pane_up": {"up", "k"}, "pane_down": {"down", "j"},
+		"pane_top": {"g", "home"}, "pane_bottom": {"G", "end"}, "confirm": {"enter"},
+		"search": {"/"}, "next_match": {"n"}, "prev_match": {"N"},
+	},
+	"emacs": {
+		"up": {"up", "ctrl+p"}, "down": {"down", "ctrl+n"}, "top": {"alt+<", "home"}, "bottom": {"alt+>", "end"},
+		"attach": {"enter", "ctrl+o"}, "add": {"a"}, "kill": {"ctrl+k"}, "autostart": {"t"}, "logs": {"l"},
+		"adopt": {"A"}, "foreign": {"F"}, "filter": {"ctrl+s", "/"}, "clear_filter": {"ctrl+g", "esc"}, "sort": {"s"},
+		"sidebar": {"b"}, "hosts": {"H"}, "refresh": {"r"}, "history": {"h"}, "notices": {"N"},
+		"dismiss": {"x"}, "help": {"ctrl+h", "?"}, "about": {"i"}, "quit": {"q"},
+		"restart": {"R"}, "palette": {"alt+x", ":"}, "theme": {"T"},
+
+		"back": {"ctrl+g", "esc", "q"}, "pane_up": {"up", "ctrl+p"}, "pane_down": {"down", "ctrl+n"},
+		"pane_top": {"alt+<", "home"}, "pane_bottom": {"alt+>", "end"}, "confirm": {"enter"},
+		"search": {"ctrl+s", "/"}, "next_match": {"n"}, "prev_match": {"N"},
+	},
+}
+
+var keyNames = map[string]string{"up": "↑", "down": "↓", "left": "←", "right": "→"}
+
+func keyHelp(keys []string) string {
+	var names []string
+	for _, k := range keys {
+		if name, ok := keyNames[k]; ok {
+			k = name
+		}
+		names = append(names, k)
+	}
+	return strings.Join(names, "/")
+}
+
+// firstKey is the first key of a binding, for the compact hints in panes.
+func firstKey(b key.Binding) string {
+	if keys := b.Keys(); len(keys) > 0 {
+		return keyHelp(keys[:1])
+	}
+	return ""
+}
+
+func (k keyMap) scrollHints() string {
+	return footerText([]keyHint{
+		{key: firstKey(k.PaneUp) + firstKey(k.PaneDown), desc: "scroll"},
+		{key: firstKey(k.PaneTop) + "/" + firstKey(k.PaneBottom), desc: "top/bottom"},
+		{key: firstKey(k.Back), desc: "back"},
+	})
+}
+
+func loadKeyMap(config Config) (keyMap, []string) {
+	var warnings []string
+	presetName := config.KeyPreset
+	if presetName == "" {
+		presetName = "default"
+	}
+	preset, ok := keyPresets[presetName]
+	if !ok {
+		warnings = append(warnings, fmt.Sprintf("unknown key preset %q, using default", presetName))
+		preset = keyPresets["default"]
+	}
+
+	var keys keyMap
+	known := make(map[string]bool)
+	for _, action := range append(keys.actions(), keys.paneActions()...) {
+		known[action.name] = true
+	}
+	var unknown []string
+	for name := range config.Keys {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		warnings = append(warnings, fmt.Sprintf("unknown key action %q in config.json", name))
+	}
+
+	bind := func(actions []keyAction) {
+		owner := make(map[string]string)
+		claim := func(action keyAction, wanted []string) []string {
+			var kept []string
+			for _, k := range wanted {
+				if k == "ctrl+c" {
+					warnings = append(warnings, fmt.Sprintf("key \"ctrl+c\" always quits, ignoring it for %s", action.name))
+					continue
+				}
+				if previous, taken := owner[k]; taken {
+					warnings = append(warnings, fmt.Sprintf("key %q is bound to both %s and %s, keeping %s", k, previous, action.name, previous))
+					continue
+				}
+				owner[k] = action.name
+				kept = append(kept, k)
+			}
+			return kept
+		}
+
+		bound := make(map[string][]string)
+		for _, action := range actions {
+			if override, ok := config.Keys[action.name]; ok {
+				bound[action.name] = claim(action, override)
+			}
+		}
+		for _, action := range actions {
+			if _, ok := config.Keys[action.name]; ok {
+				continue
+			}
+			var free []string
+			for _, k := range preset[action.name] {
+				if previous, taken := owner[k]; taken {
+					warnings = append(warnings, fmt.Sprintf("key %q now runs %s instead of %s", k, previous, action.name))
+					continue
+				}
+				free = append(free, k)
+			}
+			bound[action.name] = claim(action, free)
+		}
+
+		for _, action := range actions {
+			*action.binding = key.NewBinding(
+				key.WithKeys(bound[action.name]...),
+				key.WithHelp(keyHelp(bound[action.name]), action.desc),
+			)
+			if len(bound[action.name]) == 0 {
+				action.binding.SetEnabled(false)
+			}
+		}
+	}
+	bind(keys.actions())
+	bind(keys.paneActions())
+	return keys, warnings
+}
+
+var footerActions = []string{
+	"up", "down", "attach", "add", "kill", "logs", "palette", "help", "quit",
+	"autostart", "filter", "sort", "history", "hosts", "notices", "sidebar", "theme", "refresh",
+}
+
+func (k keyMap) footerHints() []keyHint {
+	bindings := make(map[string]*key.Binding)
+	for _, action := range k.actions() {
+		bindings[action.name] = action.binding
+	}
+	var hints []keyHint
+	for _, name := range footerActions {
+		if b := bindings[name]; b.Enabled() {
+			hints = append(hints, keyHint{action: name, key: b.Help().Key, desc: b.Help().Desc})
+		}
+	}
+	return hints
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Top, k.Bottom, k.Attach, k.Refresh},
+		{k.Add, k.Kill, k.Restart, k.Rename, k.Autostart, k.Logs, k.Adopt, k.Foreign},
+		{k.Filter, k.ClearFilter, k.Sort, k.Sidebar, k.Hosts, k.Theme},
+		{k.History, k.Notices, k.Dismiss, k.Palette, k.Help, k.About, k.Quit},
+	}
+}
+
+func (k keyMap) paneHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Back, k.Confirm, k.Search},
+		{k.PaneUp, k.PaneDown, k.NextMatch},
+		{k.PaneTop, k.PaneBottom, k.PrevMatch},
+	}
+}
+
+func (m model) helpView() string {
+	h := help.New()
+	h.Styles.FullKey = accentStyle
+	h.Styles.FullDesc = normalTextStyle
+	h.Styles.FullSeparator = mutedTextStyle
+	h.FullSeparator = "    "
+	body := accentStyle.Render("keybindings") + "\n\n" +
+		h.FullHelpView(m.keys.FullHelp()) + "\n\n" +
+		accentStyle.Render("in panes and pickers") + "\n\n" +
+		h.FullHelpView(m.keys.paneHelp()) + "\n\n" +
+		mutedTextStyle.Render(firstKey(m.keys.Back)+" close")
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, aboutStyle.Render(body))
+}
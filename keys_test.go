@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+func TestLoadKeyMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		binding  func(keyMap) key.Binding
+		wantKeys []string
+		warnings []string
+	}{
+		{
+			name:     "default preset",
+			binding:  func(k keyMap) key.Binding { return k.Kill },
+			wantKeys: []string{"k"},
+		},
+		{
+			name:     "pane keys do not clash with the list",
+			binding:  func(k keyMap) key.Binding { return k.PaneUp },
+			wantKeys: []string{"up", "k"},
+		},
+		{
+			name:     "vim preset",
+			config:   Config{KeyPreset: "vim"},
+			binding:  func(k keyMap) key.Binding { return k.Up },
+			wantKeys: []string{"up", "k"},
+		},
+		{
+			name:     "unknown preset",
+			config:   Config{KeyPreset: "dvorak"},
+			binding:  func(k keyMap) key.Binding { return k.Kill },
+			wantKeys: []string{"k"},
+			warnings: []string{`unknown key preset "dvorak"`},
+		},
+		{
+			name:     "unknown action",
+			config:   Config{Keys: map[string][]string{"frobnicate": {"z"}}},
+			binding:  func(k keyMap) key.Binding { return k.Kill },
+			wantKeys: []string{"k"},
+			warnings: []string{`unknown key action "frobnicate"`},
+		},
+		{
+			name:     "two overrides on one key",
+			config:   Config{Keys: map[string][]string{"add": {"z"}, "kill": {"z", "K"}}},
+			binding:  func(k keyMap) key.Binding { return k.Kill },
+			wantKeys: []string{"K"},
+			warnings: []string{`key "z" is bound to both add and kill, keeping add`},
+		},
+		{
+			name:     "override takes a preset key",
+			config:   Config{Keys: map[string][]string{"kill": {"q"}}},
+			binding:  func(k keyMap) key.Binding { return k.Quit },
+			warnings: []string{`key "q" now runs kill instead of quit`},
+		},
+		{
+			name:     "ctrl+c is reserved",
+			config:   Config{Keys: map[string][]string{"quit": {"ctrl+c", "Q"}}},
+			binding:  func(k keyMap) key.Binding { return k.Quit },
+			wantKeys: []string{"Q"},
+			warnings: []string{`key "ctrl+c" always quits, ignoring it for quit`},
+		},
+		{
+			name:     "pane override reusing a list key",
+			config:   Config{Keys: map[string][]string{"back": {"x"}}},
+			binding:  func(k keyMap) key.Binding { return k.Back },
+			wantKeys: []string{"x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, warnings := loadKeyMap(tt.config)
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("loadKeyMap() warnings = %q, want %q", warnings, tt.warnings)
+			}
+			for i, want := range tt.warnings {
+				if !strings.Contains(warnings[i], want) {
+					t.Errorf("warning %d = %q, want %q", i, warnings[i], want)
+				}
+			}
+			b := tt.binding(keys)
+			if strings.Join(b.Keys(), ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("keys = %q, want %q", b.Keys(), tt.wantKeys)
+			}
+			if b.Enabled() != (len(tt.wantKeys) > 0) {
+				t.Errorf("enabled = %v with keys %q", b.Enabled(), b.Keys())
+			}
+		})
+	}
+}
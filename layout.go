@@ -0,0 +1,173 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	singleColumnWidth = 80
+	minSidebarWidth   = 24
+	maxSidebarWidth   = 48
+)
+
+type layout struct {
+	width         int
+	sidebarWidth  int
+	contentWidth  int
+	sidebarHeight int
+	contentHeight int
+	singleColumn  bool
+}
+
+func (m model) layout() layout {
+	l := layout{width: m.width}
+	available := m.height - 8
+
+	switch {
+	case m.sidebarHidden:
+		l.contentWidth = l.width
+		l.contentHeight = available
+	case m.width < singleColumnWidth:
+		l.singleColumn = true
+		l.sidebarWidth = l.width
+		l.contentWidth = l.width
+		l.sidebarHeight = available / 2
+		if l.sidebarHeight < 8 {
+			l.sidebarHeight = 8
+		}
+		if l.sidebarHeight > available-5 {
+			l.sidebarHeight = available - 5
+		}
+		l.contentHeight = available - l.sidebarHeight - 2
+		if available < 12 {
+			l.sidebarHeight = available
+			l.contentHeight = 0
+		}
+	default:
+		l.sidebarWidth = l.width * 3 / 10
+		if l.sidebarWidth < minSidebarWidth {
+			l.sidebarWidth = minSidebarWidth
+		}
+		if l.sidebarWidth > maxSidebarWidth {
+			l.sidebarWidth = maxSidebarWidth
+		}
+		l.contentWidth = l.width - l.sidebarWidth
+		l.sidebarHeight = available
+		l.contentHeight = available
+	}
+	return l
+}
+
+func clipLines(text string, height int) string {
+	lines := strings.Split(text, "\n")
+	if height < 1 || len(lines) <= height {
+		return text
+	}
+	return strings.Join(lines[:height], "\n")
+}
+
+// scrollLines is clipLines starting offset lines down, with the offset
+// clamped so the last page stays full.
+func scrollLines(text string, offset, height int) string {
+	lines := strings.Split(text, "\n")
+	if height < 1 || len(lines) <= height {
+		return text
+	}
+	offset = min(max(offset, 0), len(lines)-height)
+	return strings.Join(lines[offset:offset+height], "\n")
+}
+
+// overlay draws box centered on top of background, keeping the background
+// visible around it.
+func overlay(background, box string) string {
+	lines := strings.Split(background, "\n")
+	boxLines := strings.Split(box, "\n")
+	width, boxWidth := lipgloss.Width(background), lipgloss.Width(box)
+	x := (width - boxWidth) / 2
+	y := (len(lines) - len(boxLines)) / 2
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+	for i, boxLine := range boxLines {
+		if y+i >= len(lines) {
+			break
+		}
+		line := lines[y+i]
+		left := ansi.Truncate(line, x, "")
+		if pad := x - ansi.StringWidth(left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+		right := cutLeft(line, x+lipgloss.Width(boxLine))
+		lines[y+i] = left + ansi.ResetStyle + boxLine + ansi.ResetStyle + right
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cutLeft drops the first n columns of s. The SGR sequences still in effect
+// at the cut are put back in front, so the rest of the line keeps its
+// colors, and a wide character split by the cut becomes spaces.
+func cutLeft(s string, n int) string {
+	var style strings.Builder
+	var state byte
+	col := 0
+	for len(s) > 0 && col < n {
+		seq, width, size, newState := ansi.DecodeSequence(s, state, nil)
+		state = newState
+		s = s[size:]
+		if width > 0 {
+			col += width
+			continue
+		}
+		if strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m") {
+			if seq == "\x1b[m" || seq == "\x1b[0m" {
+				style.Reset()
+			} else {
+				style.WriteString(seq)
+			}
+		}
+	}
+	return style.String() + strings.Repeat(" ", max(col-n, 0)) + s
+}
+
+type keyHint struct {
+	action string
+	key    string
+	desc   string
+}
+
+const hintSeparator = " • "
+
+func fitHints(hints []keyHint, width int) []keyHint {
+	var fitted []keyHint
+	used := 0
+	for _, hint := range hints {
+		extra := lipgloss.Width(hint.String())
+		if len(fitted) > 0 {
+			extra += lipgloss.Width(hintSeparator)
+		}
+		if used+extra > width {
+			continue
+		}
+		fitted = append(fitted, hint)
+		used += extra
+	}
+	return fitted
+}
+
+func (h keyHint) String() string {
+	return h.key + " " + h.desc
+}
+
+func footerText(hints []keyHint) string {
+	var parts []string
+	for _, hint := range hints {
+		parts = append(parts, hint.String())
+	}
+	return strings.Join(parts, hintSeparator)
+}
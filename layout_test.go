@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		width  int
+		height int
+		hidden bool
+		want   layout
+	}{
+		{"wide sidebar capped", 200, 40, false, layout{width: 200, sidebarWidth: 48, contentWidth: 152, sidebarHeight: 32, contentHeight: 32}},
+		{"sidebar at 30%", 100, 40, false, layout{width: 100, sidebarWidth: 30, contentWidth: 70, sidebarHeight: 32, contentHeight: 32}},
+		{"narrowest two columns", 80, 40, false, layout{width: 80, sidebarWidth: 24, contentWidth: 56, sidebarHeight: 32, contentHeight: 32}},
+		{"single column", 79, 40, false, layout{width: 79, sidebarWidth: 79, contentWidth: 79, sidebarHeight: 16, contentHeight: 14, singleColumn: true}},
+		{"single column short", 60, 24, false, layout{width: 60, sidebarWidth: 60, contentWidth: 60, sidebarHeight: 8, contentHeight: 6, singleColumn: true}},
+		{"single column too short for details", 60, 18, false, layout{width: 60, sidebarWidth: 60, contentWidth: 60, sidebarHeight: 10, singleColumn: true}},
+		{"sidebar hidden", 100, 40, true, layout{width: 100, contentWidth: 100, contentHeight: 32}},
+		{"sidebar hidden narrow", 60, 40, true, layout{width: 60, contentWidth: 60, contentHeight: 32}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{width: tt.width, height: tt.height, sidebarHidden: tt.hidden}
+			if got := m.layout(); got != tt.want {
+				t.Errorf("layout() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitHints(t *testing.T) {
+	hints := []keyHint{
+		{action: "attach", key: "enter", desc: "attach"},
+		{action: "logs", key: "l", desc: "logs"},
+		{action: "palette", key: ":/ctrl+p", desc: "commands"},
+		{action: "quit", key: "q", desc: "quit"},
+	}
+	tests := []struct {
+		name  string
+		width int
+		want  string
+	}{
+		{"everything fits", 100, "enter attach • l logs • :/ctrl+p commands • q quit"},
+		{"exact fit", 22, "enter attach • l logs"},
+		{"skips a long hint for a later short one", 30, "enter attach • l logs • q quit"},
+		{"one hint", 12, "enter attach"},
+		{"nothing fits", 5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := footerText(fitHints(hints, tt.width)); got != tt.want {
+				t.Errorf("fitHints(%d) = %q, want %q", tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	r := ansi.ResetStyle
+	tests := []struct {
+		name       string
+		background string
+		box        string
+		want       string
+	}{
+		{
+			"centered",
+			"abcdefghij\nabcdefghij\nabcdefghij\nabcdefghij",
+			"XX\nYY",
+			"abcdefghij\nabcd" + r + "XX" + r + "ghij\nabcd" + r + "YY" + r + "ghij\nabcdefghij",
+		},
+		{"box wider than background", "abc\nabc", "WXYZ", r + "WXYZ" + r + "\nabc"},
+		{"box taller than background", "abc", "X\nY\nZ", "a" + r + "X" + r + "c"},
+		{"short line is padded", "abcdefghij\nab\nabcdefghij", "XY", "abcdefghij\nab  " + r + "XY" + r + "\nabcdefghij"},
+		{"wide characters", "日本語日本", "X", "日本" + r + "X" + r + " 日本"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overlay(tt.background, tt.box); got != tt.want {
+				t.Errorf("overlay() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutLeft(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"plain", "abcdef", 2, "cdef"},
+		{"nothing cut", "abcdef", 0, "abcdef"},
+		{"everything cut", "abc", 5, ""},
+		{"color still active", "\x1b[31mabc\x1b[0mdef", 1, "\x1b[31mbc\x1b[0mdef"},
+		{"color already reset", "\x1b[31mab\x1b[0mcd", 3, "d"},
+		{"stacked styles", "\x1b[1m\x1b[32mab", 1, "\x1b[1m\x1b[32mb"},
+		{"split wide character", "日本", 1, " 本"},
+		{"whole wide character", "日本", 2, "本"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cutLeft(tt.s, tt.n); got != tt.want {
+				t.Errorf("cutLeft(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+		})
+	}
+}
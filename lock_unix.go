@@ -0,0 +1,46 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// withFileLock runs fn while holding an exclusive lock on base+".lock".
+// base must be an absolute path, so an unset path can't leave a stray
+// ".lock" in the working directory.
+func withFileLock(base string, fn func() error) error {
+	if base == "" || !filepath.IsAbs(base) {
+		return fmt.Errorf("refusing to lock %q, expected an absolute path", base)
+	}
+	f, err := os.OpenFile(base+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return fn()
+}
+
+// tryFileLock takes an exclusive lock on base+".lock" without waiting. The
+// lock is held until the returned file is closed or the process exits.
+func tryFileLock(base string) (*os.File, bool) {
+	if base == "" || !filepath.IsAbs(base) {
+		return nil, false
+	}
+	f, err := os.OpenFile(base+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, false
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, false
+	}
+	return f, true
+}
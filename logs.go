@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultLogMaxSizeMB = 10
+	defaultLogKeep      = 5
+	logTailBytes        = 1 << 20
+)
+
+type LogSettings struct {
+	Disabled  bool   `json:"disabled,omitempty"`
+	MaxSizeMB int    `json:"max_size_mb,omitempty"`
+	MaxAge    string `json:"max_age,omitempty"`
+	Keep      int    `json:"keep,omitempty"`
+}
+
+var ansiPattern = regexp.MustCompile(`\x1b(\[[0-9;?]*[ -/]*[@-~]|\][^\x07]*\x07|[()][0-9A-Za-z]|[=>])`)
+
+func logsDir() string {
+	return filepath.Join(stateDir, "logs")
+}
+
+func sessionLogFile(name string) string {
+	return filepath.Join(logsDir(), name+".log")
+}
+
+func loggingEnabled(entry SessionEntry) bool {
+	return entry.Log == nil || !entry.Log.Disabled
+}
+
+func screenLogArgs(entry SessionEntry) []string {
+	if !loggingEnabled(entry) {
+		return nil
+	}
+	return []string{"-L", "-Logfile", sessionLogFile(entry.Name)}
+}
+
+func rotateSessionLog(entry SessionEntry) error {
+	settings := LogSettings{}
+	if entry.Log != nil {
+		settings = *entry.Log
+	}
+	maxSize := int64(settings.MaxSizeMB) << 20
+	if maxSize <= 0 {
+		maxSize = defaultLogMaxSizeMB << 20
+	}
+	keep := settings.Keep
+	if keep <= 0 {
+		keep = defaultLogKeep
+	}
+	var maxAge time.Duration
+	if settings.MaxAge != "" {
+		age, err := time.ParseDuration(settings.MaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid max_age for session %s: %v", entry.Name, err)
+		}
+		maxAge = age
+	}
+
+	logFile := sessionLogFile(entry.Name)
+	info, err := os.Stat(logFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	marker := logFile + ".rotated"
+	markerInfo, err := os.Stat(marker)
+	if os.IsNotExist(err) {
+		if err := os.WriteFile(marker, nil, 0644); err != nil {
+			return err
+		}
+		markerInfo, err = os.Stat(marker)
+	}
+	if err != nil {
+		return err
+	}
+
+	expired := maxAge > 0 && time.Since(markerInfo.ModTime()) >= maxAge
+	if info.Size() < maxSize && !(expired && info.Size() > 0) {
+		return nil
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", logFile, keep))
+	for i := keep - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", logFile, i), fmt.Sprintf("%s.%d", logFile, i+1))
+	}
+
+	// screen keeps the log open in append mode, so copy and truncate in
+	// place instead of renaming the file out from under it.
+	src, err := os.Open(logFile)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(logFile + ".1")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := os.Truncate(logFile, 0); err != nil {
+		return err
+	}
+	now := time.Now()
+	return os.Chtimes(marker, now, now)
+}
+
+func rotateSessionLogs() {
+	store, _ := loadStore()
+	for _, entry := range store.Sessions {
+		if loggingEnabled(entry) {
+			rotateSessionLog(entry)
+		}
+	}
+}
+
+func cleanLogOutput(data []byte) string {
+	text := ansiPattern.ReplaceAllString(string(data), "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "")
+}
+
+func readLogTail(name string, max int64) (string, error) {
+	f, err := os.Open(sessionLogFile(name))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := int64(0)
+	if info.Size() > max {
+		offset = info.Size() - max
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	text := cleanLogOutput(data)
+	if offset > 0 {
+		if i := strings.Index(text, "\n"); i >= 0 {
+			text = text[i+1:]
+		}
+	}
+	return text, nil
+}
+
+func followLog(name string, follow bool, w io.Writer) error {
+	f, err := os.Open(sessionLogFile(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no log for session %s", name)
+		}
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 32*1024)
+	var offset int64
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			offset += int64(n)
+			io.WriteString(w, cleanLogOutput(buf[:n]))
+		}
+		if err == io.EOF {
+			if !follow {
+				return nil
+			}
+			time.Sleep(500 * time.Millisecond)
+			if info, statErr := f.Stat(); statErr == nil && info.Size() < offset {
+				f.Seek(0, io.SeekStart)
+				offset = 0
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func highlightMatches(text, query string) (string, []int) {
+	lines := strings.Split(text, "\n")
+	var matches []int
+	if query == "" {
+		return text, matches
+	}
+	for i, line := range lines {
+		haystack, needle := line, query
+		if lower := strings.ToLower(line); len(lower) == len(line) && len(strings.ToLower(query)) == len(query) {
+			haystack, needle = lower, strings.ToLower(query)
+		}
+		if !strings.Contains(haystack, needle) {
+			continue
+		}
+		matches = append(matches, i)
+		var highlighted strings.Builder
+		for {
+			idx := strings.Index(haystack, needle)
+			if idx < 0 {
+				highlighted.WriteString(line)
+				break
+			}
+			highlighted.WriteString(line[:idx])
+			highlighted.WriteString(selectedStyle.Render(line[idx : idx+len(needle)]))
+			line = line[idx+len(needle):]
+			haystack = haystack[idx+len(needle):]
+		}
+		lines[i] = highlighted.String()
+	}
+	return strings.Join(lines, "\n"), matches
+}
+
+type logMsg struct {
+	session string
+	host    string
+	text    string
+	err     error
+}
+
+func previewCmd(remote remoteBackend, session string) tea.Cmd {
+	return func() tea.Msg {
+		text, err := remote.preview(session)
+		return logMsg{session: session, host: remote.host.Name, text: text, err: err}
+	}
+}
+
+func logTailCmd(session string) tea.Cmd {
+	return timeoutCmd(func() tea.Msg {
+		text, err := readLogTail(session, logTailBytes)
+		return logMsg{session: session, text: text, err: err}
+	}, logMsg{session: session, err: fmt.Errorf("reading the log of %s timed out", session)})
+}
+
+// refreshLogView rereads the log of the session being viewed in the
+// background; the result is shown on logMsg. Remote sessions are fetched
+// over ssh.
+func (m *model) refreshLogView() tea.Cmd {
+	if m.logLoading {
+		return nil
+	}
+	m.logLoading = true
+	if remote, ok := m.backendFor(screenSession{host: m.logHost}).(remoteBackend); ok {
+		return previewCmd(remote, m.logSession)
+	}
+	return logTailCmd(m.logSession)
+}
+
+func (m *model) applyLog(msg logMsg) {
+	m.logLoading = false
+	if msg.session != m.logSession || msg.host != m.logHost {
+		return
+	}
+	m.logText, m.logErr = msg.text, msg.err
+	m.showLog()
+}
+
+func (m *model) showLog() {
+	width := m.width - 6
+	height := m.height - 8
+	if width < 10 {
+		width = 10
+	}
+	if height < 3 {
+		height = 3
+	}
+	m.logViewport.Width = width
+	m.logViewport.Height = height
+
+	text, err := m.logText, m.logErr
+	if err != nil {
+		if os.IsNotExist(err) {
+			text = mutedTextStyle.Render("No output logged for this session yet.")
+		} else {
+			text = errorTextStyle.Render(err.Error())
+		}
+	}
+	following := m.logViewport.AtBottom()
+	text, m.logMatches = highlightMatches(text, m.logQuery)
+	m.logViewport.SetContent(text)
+	if following {
+		m.logViewport.GotoBottom()
+	}
+}
+
+func (m *model) jumpToLogMatch(step int) {
+	if len(m.logMatches) == 0 {
+		return
+	}
+	m.logMatch = (m.logMatch + step + len(m.logMatches)) % len(m.logMatches)
+	m.logViewport.SetYOffset(m.logMatches[m.logMatch])
+}
+
+func (m model) logView() string {
+	title := accentStyle.Render("logs: " + m.logSession)
+	if m.logHost != "" {
+		title = accentStyle.Render("screen: " + m.logSession + "@" + m.logHost)
+	}
+	position := fmt.Sprintf("%3.f%%", m.logViewport.ScrollPercent()*100)
+	if m.logQuery != "" {
+		if len(m.logMatches) == 0 {
+			position = "no matches • " + position
+		} else {
+			position = fmt.Sprintf("match %d/%d • %s", m.logMatch+1, len(m.logMatches), position)
+		}
+	}
+
+	bottom := mutedTextStyle.Render(footerText([]keyHint{
+		{key: firstKey(m.keys.PaneUp) + firstKey(m.keys.PaneDown), desc: "scroll"},
+		{key: firstKey(m.keys.Search), desc: "search"},
+		{key: firstKey(m.keys.NextMatch) + "/" + firstKey(m.keys.PrevMatch), desc: "next/prev"},
+		{key: firstKey(m.keys.PaneTop) + "/" + firstKey(m.keys.PaneBottom), desc: "top/bottom"},
+		{key: firstKey(m.keys.Back), desc: "back"},
+	}))
+	if m.state == searchingLogs {
+		bottom = accentStyle.Render("/") + m.textInput.View()
+	}
+
+	box := contentStyle.Copy().Width(m.width - 2).Render(lipgloss.JoinVertical(
+		lipgloss.Left,
+		title+"  "+mutedTextStyle.Render(position),
+		"",
+		m.logViewport.View(),
+		"",
+		bottom,
+	))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
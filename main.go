@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -12,7 +15,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/shirou/gopsutil/v3/cpu"
@@ -33,6 +38,17 @@ const (
 	addingCommand
 	addingDescription
 	showingAbout
+	viewingLogs
+	searchingLogs
+	showingHistory
+	selectingHost
+	showingNotices
+	showingHelp
+	filteringSessions
+	adoptingName
+	adoptingCommand
+	showingPalette
+	selectingTheme
 )
 
 type tickMsg time.Time
@@ -45,6 +61,24 @@ type screenSession struct {
 	autostart   bool
 	command     string
 	description string
+	dependsOn   []string
+	bootStatus  string
+	bootDetail  string
+	started     time.Time
+	createdAt   time.Time
+	lastSeen    time.Time
+	exitedAt    time.Time
+	exitCode    string
+	exitCodeAt  time.Time
+	host        string
+	foreign     bool
+}
+
+func (s screenSession) screenName() string {
+	if s.foreign {
+		return s.id + "." + s.name
+	}
+	return s.id + ".spv_" + s.name
 }
 
 type model struct {
@@ -60,7 +94,58 @@ type model struct {
 	cpuUsage        float64
 	memUsage        float64
 	commitMsg       string
-	errorMsg        string
+	notices         []notice
+	noticeLog       []notice
+	nextNotice      int
+	noticeViewport  viewport.Model
+	logViewport     viewport.Model
+	logSession      string
+	logQuery        string
+	logMatches      []int
+	logMatch        int
+	logText         string
+	logErr          error
+	logLoading      bool
+	watcher         *logWatcher
+	scanning        bool
+	statsLoading    bool
+	alerts          map[string]sessionAlert
+	backend         sessionBackend
+	exits           *exitDetector
+	historyViewport viewport.Model
+	historyEvents   []historyEvent
+	historyErr      error
+	historyLoading  bool
+	host            string
+	hostGen         int
+	hostStatus      string
+	hostCursor      int
+	paletteCursor   int
+	paletteCommand  string
+	paletteTarget   screenSession
+	themeCursor     int
+	themeBefore     string
+	logHost         string
+	sortMode        sortMode
+	filter          string
+	hostStatuses    map[string]string
+	showForeign     bool
+	sidebarHidden   bool
+	keys            keyMap
+	lastClick       time.Time
+	lastClickIndex  int
+	detailsScroll   int
+	detailsFor      string
+	adopting        screenSession
+	adoptCommand    string
+	refreshGen      int
+	refreshing      bool
+	refreshQueued   bool
+	refreshPending  bool
+	refreshErr      error
+	lastRefresh     time.Time
+	changes         <-chan struct{}
+	pollEvery       time.Duration
 }
 
 type Theme struct {
@@ -74,6 +159,14 @@ type Theme struct {
 	SelectedFg     lipgloss.Color
 	StatusAttached lipgloss.Color
 	StatusDetached lipgloss.Color
+	Title          lipgloss.Color
+	ErrorBg        lipgloss.Color
+	ErrorFg        lipgloss.Color
+	LinkGit        lipgloss.Color
+	LinkBluesky    lipgloss.Color
+	LinkLinkedIn   lipgloss.Color
+	LinkSoundCloud lipgloss.Color
+	Monochrome     bool
 }
 
 var themes = map[string]Theme{
@@ -185,20 +278,30 @@ var themes = map[string]Theme{
 		StatusAttached: lipgloss.Color("#A3BE8C"),
 		StatusDetached: lipgloss.Color("#EBCB8B"),
 	},
+	"mono": monoTheme,
 }
 
+// monoTheme is kept apart from themes so that NO_COLOR still gets the
+// built-in monochrome theme when a user file replaces "mono".
+var monoTheme = Theme{Monochrome: true}
+
 var (
 	headerStyle, sidebarStyle, contentStyle, selectedStyle,
 	statusAttachedStyle, statusDetachedStyle, accentStyle,
 	footerStyle, inputStyle, aboutStyle, overflowStyle,
-	mutedTextStyle, normalTextStyle, errorTextStyle lipgloss.Style
+	mutedTextStyle, normalTextStyle, errorTextStyle, titleStyle,
+	gitLinkStyle, blueskyLinkStyle, linkedInLinkStyle, soundCloudLinkStyle lipgloss.Style
 )
 
+var currentTheme string
+
 func applyTheme(name string) {
 	theme, ok := themes[name]
 	if !ok {
-		theme = themes["slate"]
+		name, theme = "slate", themes["slate"]
 	}
+	currentTheme = name
+	theme = theme.withDefaults().forProfile(colorProfile)
 
 	headerStyle = lipgloss.NewStyle().
 		Foreground(theme.Text).Background(theme.HeaderBg).
@@ -207,12 +310,12 @@ func applyTheme(name string) {
 
 	sidebarStyle = lipgloss.NewStyle().
 		Foreground(theme.Text).Background(theme.PanelBg).
-		Padding(1, 2).Width(32).Border(lipgloss.NormalBorder()).
+		Padding(1, 2).Border(lipgloss.NormalBorder()).
 		BorderForeground(theme.Border)
 
 	contentStyle = lipgloss.NewStyle().
 		Foreground(theme.Text).Background(theme.PanelBg).
-		Padding(1, 2).Width(44).Border(lipgloss.NormalBorder()).
+		Padding(1, 2).Border(lipgloss.NormalBorder()).
 		BorderForeground(theme.Border)
 
 	selectedStyle = lipgloss.NewStyle().
@@ -253,122 +356,196 @@ func applyTheme(name string) {
 		Foreground(theme.Text)
 
 	errorTextStyle = lipgloss.NewStyle().
-		Background(lipgloss.Color("#FF0000")).
-		Foreground(lipgloss.Color("#FFFFFF")).
+		Background(theme.ErrorBg).
+		Foreground(theme.ErrorFg).
 		Padding(0, 1).
 		Bold(true)
+
+	titleStyle = lipgloss.NewStyle().
+		Foreground(theme.Title).Bold(true)
+
+	gitLinkStyle = lipgloss.NewStyle().Foreground(theme.LinkGit)
+	blueskyLinkStyle = lipgloss.NewStyle().Foreground(theme.LinkBluesky)
+	linkedInLinkStyle = lipgloss.NewStyle().Foreground(theme.LinkLinkedIn)
+	soundCloudLinkStyle = lipgloss.NewStyle().Foreground(theme.LinkSoundCloud)
+
+	if theme.Monochrome {
+		selectedStyle = selectedStyle.Reverse(true)
+		errorTextStyle = errorTextStyle.Reverse(true)
+		statusDetachedStyle = statusDetachedStyle.Bold(false).Underline(true)
+	}
 }
 
 type Config struct {
-	Theme string `json:"theme"`
+	Theme            string              `json:"theme"`
+	AutostartBackend string              `json:"autostart_backend,omitempty"`
+	MetricsListen    string              `json:"metrics_listen,omitempty"`
+	Hooks            []Hook              `json:"hooks,omitempty"`
+	Hosts            []HostProfile       `json:"hosts,omitempty"`
+	ShowForeign      bool                `json:"show_foreign,omitempty"`
+	PollInterval     string              `json:"poll_interval,omitempty"`
+	KeyPreset        string              `json:"key_preset,omitempty"`
+	Keys             map[string][]string `json:"keys,omitempty"`
 }
 
 type SessionEntry struct {
-	Name        string `json:"name"`
-	Command     string `json:"command"`
-	Description string `json:"description"`
-	Cwd         string `json:"cwd"`
+	Name        string       `json:"name"`
+	Command     string       `json:"command"`
+	Description string       `json:"description"`
+	Cwd         string       `json:"cwd"`
+	DependsOn   []string     `json:"depends_on,omitempty"`
+	Ready       *ReadyCheck  `json:"ready,omitempty"`
+	Autostart   bool         `json:"autostart,omitempty"`
+	Log         *LogSettings `json:"log,omitempty"`
+	Watch       []WatchRule  `json:"watch,omitempty"`
+	Restarts    int          `json:"restarts,omitempty"`
+	CreatedAt   time.Time    `json:"created_at,omitzero"`
+	StartedAt   time.Time    `json:"started_at,omitzero"`
+	LastSeen    time.Time    `json:"last_seen,omitzero"`
+	ExitedAt    time.Time    `json:"exited_at,omitzero"`
+	ExitFile    string       `json:"exit_file,omitempty"`
+}
+
+type ReadyCheck struct {
+	Type    string `json:"type"`
+	Target  string `json:"target"`
+	Pattern string `json:"pattern,omitempty"`
+	Timeout int    `json:"timeout,omitempty"`
 }
 
 type SystemInfo struct {
-	OS           string
-	Distribution string
-	InitSystem   string
+	OS               string
+	Distribution     string
+	InitSystem       string
+	AutostartBackend string
+	AutostartReason  string
 }
 
-var configDir, configFile, sessionFile, autostartFile string
+var configDir, stateDir, runtimeDir string
+var configFile, sessionFile, autostartFile, autostartStatusFile, autostartBackendFile string
 
-func setupPaths() {
+func xdgDir(envVar string, fallback ...string) (string, error) {
+	if dir := os.Getenv(envVar); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, "spv"), nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("cannot determine home directory (set %s or use --config-dir): %v", envVar, err)
+	}
+	return filepath.Join(append([]string{home}, append(fallback, "spv")...)...), nil
+}
+
+func setupPaths(override string) error {
+	if override == "" {
+		override = os.Getenv("SPV_CONFIG_DIR")
+	}
+
+	var err error
+	if override != "" {
+		if configDir, err = filepath.Abs(override); err != nil {
+			return fmt.Errorf("invalid config directory %s: %v", override, err)
+		}
+		stateDir = filepath.Join(configDir, "state")
+		runtimeDir = filepath.Join(configDir, "run")
+	} else {
+		if configDir, err = xdgDir("XDG_CONFIG_HOME", ".config"); err != nil {
+			return err
+		}
+		if stateDir, err = xdgDir("XDG_STATE_HOME", ".local", "state"); err != nil {
+			return err
+		}
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && filepath.IsAbs(dir) {
+			runtimeDir = filepath.Join(dir, "spv")
+		} else {
+			runtimeDir = filepath.Join(os.TempDir(), fmt.Sprintf("spv-%d", os.Getuid()))
+			if err := ensurePrivateDir(runtimeDir); err != nil {
+				return err
+			}
+		}
 	}
-	configDir = filepath.Join(home, ".config", "spv")
+
 	configFile = filepath.Join(configDir, "config.json")
 	sessionFile = filepath.Join(configDir, "sessions.json")
 	autostartFile = filepath.Join(configDir, "autostart.json")
-	os.MkdirAll(configDir, os.ModePerm)
-}
+	autostartStatusFile = filepath.Join(stateDir, "autostart-status")
+	autostartBackendFile = filepath.Join(stateDir, "autostart-backend")
 
-func loadTheme() string {
-	data, err := os.ReadFile(configFile)
-	if err != nil {
-		return "slate"
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory %s: %v", configDir, err)
 	}
-	var cfg Config
-	if json.Unmarshal(data, &cfg) != nil {
-		return "slate"
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory %s: %v", stateDir, err)
 	}
-	if _, ok := themes[cfg.Theme]; !ok {
-		return "slate"
+	if err := os.MkdirAll(runtimeDir, 0700); err != nil {
+		return fmt.Errorf("failed to create runtime directory %s: %v", runtimeDir, err)
 	}
-	return cfg.Theme
+	return nil
 }
 
-func saveTheme(name string) error {
-	cfg := Config{Theme: name}
-	data, err := json.MarshalIndent(cfg, "", "  ")
+// ensurePrivateDir creates dir in a shared location like /tmp, refusing one
+// that someone else could have planted there first.
+func ensurePrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to create runtime directory %s: %v", dir, err)
+	}
+	info, err := os.Lstat(dir)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(configFile, data, 0644)
+	if !info.IsDir() {
+		return fmt.Errorf("runtime directory %s is not a directory (set XDG_RUNTIME_DIR or use --config-dir)", dir)
+	}
+	if owner, ok := fileOwner(info); ok && owner != os.Getuid() {
+		return fmt.Errorf("runtime directory %s is owned by uid %d, not you (set XDG_RUNTIME_DIR or use --config-dir)", dir, owner)
+	}
+	if info.Mode().Perm() != 0700 {
+		return fmt.Errorf("runtime directory %s has mode %o, expected 700", dir, info.Mode().Perm())
+	}
+	return nil
 }
 
-func readConfig(file string) ([]SessionEntry, error) {
-	data, err := os.ReadFile(file)
+func loadConfig() Config {
+	var cfg Config
+	data, err := os.ReadFile(configFile)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []SessionEntry{}, nil
-		}
-		return nil, err
+		return cfg
 	}
-	var entries []SessionEntry
-	err = json.Unmarshal(data, &entries)
-	return entries, err
+	json.Unmarshal(data, &cfg)
+	return cfg
 }
 
-func writeConfig(file string, entries []SessionEntry) error {
-	data, err := json.MarshalIndent(entries, "", "  ")
+func saveConfig(cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(file, data, 0644)
+	return writeFileAtomic(configFile, data, 0644)
 }
 
-func addSessionEntry(name, command, description, cwd string) error {
-	entries, err := readConfig(sessionFile)
-	if err != nil {
-		return err
+func loadTheme() string {
+	cfg := loadConfig()
+	if _, ok := themes[cfg.Theme]; !ok {
+		return "slate"
 	}
-	entries = append(entries, SessionEntry{
-		Name:        name,
-		Command:     command,
-		Description: description,
-		Cwd:         cwd,
-	})
-	return writeConfig(sessionFile, entries)
+	return cfg.Theme
 }
 
-func removeEntry(file, name string) error {
-	entries, err := readConfig(file)
-	if err != nil {
-		return err
-	}
-	var updatedEntries []SessionEntry
-	for _, entry := range entries {
-		if entry.Name != name {
-			updatedEntries = append(updatedEntries, entry)
-		}
-	}
-	return writeConfig(file, updatedEntries)
+func saveTheme(name string) error {
+	return withFileLock(configFile, func() error {
+		cfg := loadConfig()
+		cfg.Theme = name
+		return saveConfig(cfg)
+	})
 }
 
-func detectSystem() SystemInfo {
+func detectSystem() (SystemInfo, error) {
 	info := SystemInfo{OS: runtime.GOOS}
+	var err error
 	switch runtime.GOOS {
 	case "linux":
 		info.Distribution = detectLinuxDistribution()
 		info.InitSystem = detectInitSystem()
+		info.AutostartBackend, info.AutostartReason, err = chooseAutostartBackend(info.InitSystem, loadConfig().AutostartBackend)
 	case "darwin":
 		info.Distribution = "macOS"
 		info.InitSystem = "launchd"
@@ -376,7 +553,7 @@ func detectSystem() SystemInfo {
 		info.Distribution = "unknown"
 		info.InitSystem = "unknown"
 	}
-	return info
+	return info, err
 }
 
 func detectLinuxDistribution() string {
@@ -407,6 +584,15 @@ func detectInitSystem() string {
 	if _, err := os.Stat("/run/systemd/system"); err == nil {
 		return "systemd"
 	}
+	if _, err := os.Stat("/run/dinitctl"); err == nil {
+		return "dinit"
+	}
+	if _, err := os.Stat("/run/runit"); err == nil {
+		return "runit"
+	}
+	if _, err := os.Stat("/run/s6"); err == nil {
+		return "s6"
+	}
 	if _, err := os.Stat("/sbin/openrc"); err == nil {
 		return "openrc"
 	}
@@ -419,22 +605,255 @@ func detectInitSystem() string {
 	return "unknown"
 }
 
+var autostartBackends = []string{"systemd", "openrc", "sysvinit", "runit", "s6", "dinit", "cron"}
+
+// chooseAutostartBackend returns the backend to install and why it was
+// picked, so falling back to cron is never silent.
+func chooseAutostartBackend(initSystem, override string) (string, string, error) {
+	if override != "" && override != "auto" {
+		for _, backend := range autostartBackends {
+			if backend == override {
+				return backend, "set by autostart_backend in config.json", nil
+			}
+		}
+		return "", "", fmt.Errorf("invalid autostart_backend %q in config.json, valid values are auto, %s", override, strings.Join(autostartBackends, ", "))
+	}
+	if os.Geteuid() != 0 {
+		return "cron", "spv is not running as root, so it cannot install a system service", nil
+	}
+	for _, backend := range autostartBackends {
+		if backend == initSystem {
+			return backend, "detected init system", nil
+		}
+	}
+	return "cron", fmt.Sprintf("init system %q is not supported", initSystem), nil
+}
+
+// installedAutostartBackend is the backend the boot script was last
+// installed with, or "" if there is none.
+func installedAutostartBackend() string {
+	data, err := os.ReadFile(autostartBackendFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func autostartScriptPath(backend string) string {
+	if backend == "cron" {
+		return filepath.Join(configDir, "spv-autostart.sh")
+	}
+	return "/usr/local/bin/spv-autostart.sh"
+}
+
+func firstExistingDir(dirs ...string) string {
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+func runitServiceDir() string {
+	return firstExistingDir("/var/service", "/etc/runit/runsvdir/default", "/etc/service")
+}
+
+func s6ScanDir() string {
+	return firstExistingDir("/run/service", "/service", "/etc/service")
+}
+
+const cronMarker = "# spv-autostart"
+
+func readCrontab() (string, error) {
+	output, err := exec.Command("crontab", "-l").CombinedOutput()
+	if err != nil {
+		if strings.Contains(strings.ToLower(string(output)), "no crontab") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read crontab: %v", err)
+	}
+	return string(output), nil
+}
+
+func writeCrontab(content string) error {
+	cmd := exec.Command("crontab", "-")
+	cmd.Stdin = strings.NewReader(content)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write crontab: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func stripCronEntry(crontab string) string {
+	var lines []string
+	for _, line := range strings.Split(crontab, "\n") {
+		if strings.HasSuffix(strings.TrimSpace(line), cronMarker) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func orderAutostartSessions(sessions []SessionEntry) ([]SessionEntry, error) {
+	byName := make(map[string]SessionEntry)
+	for _, session := range sessions {
+		byName[session.Name] = session
+	}
+	for _, session := range sessions {
+		for _, dep := range session.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("session %s depends on %s, which is not set to autostart", session.Name, dep)
+			}
+		}
+	}
+
+	var ordered []SessionEntry
+	done := make(map[string]bool)
+	visiting := make(map[string]bool)
+	var visit func(session SessionEntry) error
+	visit = func(session SessionEntry) error {
+		if done[session.Name] {
+			return nil
+		}
+		if visiting[session.Name] {
+			return fmt.Errorf("dependency cycle involving session %s", session.Name)
+		}
+		visiting[session.Name] = true
+		for _, dep := range session.DependsOn {
+			if err := visit(byName[dep]); err != nil {
+				return err
+			}
+		}
+		visiting[session.Name] = false
+		done[session.Name] = true
+		ordered = append(ordered, session)
+		return nil
+	}
+	for _, session := range sessions {
+		if err := visit(session); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func readyVarName(name string) string {
+	return "ready_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func readyCheckCommand(check *ReadyCheck) (string, error) {
+	switch check.Type {
+	case "tcp":
+		host, port := "127.0.0.1", check.Target
+		if h, p, err := net.SplitHostPort(check.Target); err == nil {
+			host, port = h, p
+			if host == "" {
+				host = "127.0.0.1"
+			}
+		}
+		return fmt.Sprintf("check_tcp %s %s", shellQuote(host), shellQuote(port)), nil
+	case "file":
+		return fmt.Sprintf("test -e %s", shellQuote(check.Target)), nil
+	case "log":
+		return fmt.Sprintf("grep -qE %s %s", shellQuote(check.Pattern), shellQuote(check.Target)), nil
+	case "command":
+		return fmt.Sprintf("bash -c %s", shellQuote(check.Target)), nil
+	default:
+		return "", fmt.Errorf("unknown readiness check type: %s", check.Type)
+	}
+}
+
 func generateAutostartScriptContent(autostartSessions []SessionEntry) (string, error) {
+	ordered, err := orderAutostartSessions(autostartSessions)
+	if err != nil {
+		return "", err
+	}
+
 	var script strings.Builder
 	script.WriteString("#!/bin/bash\n")
-	script.WriteString("sleep 15\n\n")
+	script.WriteString(fmt.Sprintf("mkdir -p %s %s\n", shellQuote(logsDir()), shellQuote(filepath.Join(stateDir, "exit"))))
+	script.WriteString(fmt.Sprintf("status_file=%s\n", shellQuote(autostartStatusFile)))
+	script.WriteString(`: > "$status_file"
+
+report() {
+    printf '%s\t%s\t%s\t%s\n' "$(date +%s)" "$1" "$2" "$3" >> "$status_file"
+}
+
+check_tcp() {
+    (exec 3<>"/dev/tcp/$1/$2") 2>/dev/null
+}
+
+wait_ready() {
+    local name=$1 timeout=$2 check=$3
+    local deadline=$(( $(date +%s) + timeout ))
+    until eval "$check" >/dev/null 2>&1; do
+        if [ "$(date +%s)" -ge "$deadline" ]; then
+            report "$name" timeout "no answer within ${timeout}s"
+            return 1
+        fi
+        sleep 1
+    done
+    report "$name" ready
+}
+
+`)
 
-	for _, session := range autostartSessions {
-		escapedCommand := strings.ReplaceAll(session.Command, `"`, `\"`)
+	for _, session := range ordered {
 		cwd := session.Cwd
 		if cwd == "" {
 			cwd = os.Getenv("HOME")
 		}
-		script.WriteString(fmt.Sprintf("cd %s && ", cwd))
+		readyVar := readyVarName(session.Name)
+
+		indent := ""
+		if len(session.DependsOn) > 0 {
+			indent = "    "
+			var checks []string
+			for _, dep := range session.DependsOn {
+				checks = append(checks, fmt.Sprintf(`[ "$%s" = 1 ]`, readyVarName(dep)))
+			}
+			script.WriteString(fmt.Sprintf("if %s; then\n", strings.Join(checks, " && ")))
+		}
+
+		script.WriteString(indent + fmt.Sprintf("cd %s && ", shellQuote(cwd)))
+		logArgs := ""
+		if loggingEnabled(session) {
+			logArgs = fmt.Sprintf("-L -Logfile %s ", shellQuote(sessionLogFile(session.Name)))
+		}
 		if session.Command == "shell" || session.Command == "" {
-			script.WriteString(fmt.Sprintf("screen -dmS spv_%s\n", session.Name))
+			script.WriteString(fmt.Sprintf("screen %s-dmS spv_%s\n", logArgs, session.Name))
+		} else {
+			inner := session.Command + "; echo $? > " + shellQuote(session.exitFile()) + "; exec bash"
+			script.WriteString(fmt.Sprintf("screen %s-dmS spv_%s bash -c %s\n", logArgs, session.Name, shellQuote(inner)))
+		}
+
+		if session.Ready != nil {
+			check, err := readyCheckCommand(session.Ready)
+			if err != nil {
+				return "", fmt.Errorf("session %s: %v", session.Name, err)
+			}
+			timeout := session.Ready.Timeout
+			if timeout <= 0 {
+				timeout = 60
+			}
+			script.WriteString(indent + fmt.Sprintf("wait_ready %s %d %s && %s=1\n", shellQuote(session.Name), timeout, shellQuote(check), readyVar))
 		} else {
-			script.WriteString(fmt.Sprintf("screen -dmS spv_%s bash -c \"%s; exec bash\"\n", session.Name, escapedCommand))
+			script.WriteString(indent + fmt.Sprintf("report %s started\n%s%s=1\n", shellQuote(session.Name), indent, readyVar))
+		}
+
+		if len(session.DependsOn) > 0 {
+			script.WriteString(fmt.Sprintf("else\n    report %s skipped %s\nfi\n", shellQuote(session.Name), shellQuote("waiting on "+strings.Join(session.DependsOn, ", "))))
 		}
 	}
 	script.WriteString("\nexit 0\n")
@@ -446,12 +865,12 @@ func createLinuxAutostart(autostartSessions []SessionEntry, sysInfo SystemInfo)
 	if err != nil {
 		return err
 	}
-	scriptPath := "/usr/local/bin/spv-autostart.sh"
+	scriptPath := autostartScriptPath(sysInfo.AutostartBackend)
 	if err := os.WriteFile(scriptPath, []byte(scriptContent), 0755); err != nil {
 		return fmt.Errorf("failed to create autostart script %s: %v", scriptPath, err)
 	}
 
-	switch sysInfo.InitSystem {
+	switch sysInfo.AutostartBackend {
 	case "systemd":
 		serviceContent := `[Unit]
 Description=SPV Screen Session Autostart
@@ -584,7 +1003,7 @@ stop() {
     screen -ls | grep -E "spv_" | cut -d. -f1 | awk '{print $2}' | xargs -r -I {} screen -S {} -X quit
     eend $?
 }
-`, strings.ReplaceAll(scriptContent, "\n", "\n    "))
+`, scriptPath)
 
 		rcScriptPath := "/etc/init.d/spv-autostart"
 		if err := os.WriteFile(rcScriptPath, []byte(rcScriptContent), 0755); err != nil {
@@ -594,15 +1013,92 @@ stop() {
 			return fmt.Errorf("failed to enable OpenRC service: %v", err)
 		}
 		return nil
+	case "runit":
+		serviceDir := "/etc/sv/spv-autostart"
+		runContent := fmt.Sprintf(`#!/bin/sh
+exec 2>&1
+%s
+exec tail -f /dev/null
+`, scriptPath)
+		if err := os.MkdirAll(serviceDir, 0755); err != nil {
+			return fmt.Errorf("failed to create runit service directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(serviceDir, "run"), []byte(runContent), 0755); err != nil {
+			return fmt.Errorf("failed to create runit run script: %v", err)
+		}
+		linkDir := runitServiceDir()
+		if linkDir == "" {
+			return fmt.Errorf("failed to find runit service directory")
+		}
+		link := filepath.Join(linkDir, "spv-autostart")
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			if err := os.Symlink(serviceDir, link); err != nil {
+				return fmt.Errorf("failed to enable runit service: %v", err)
+			}
+		}
+		return nil
+	case "s6":
+		serviceDir := "/etc/s6/sv/spv-autostart"
+		runContent := fmt.Sprintf(`#!/bin/sh
+exec 2>&1
+%s
+exec tail -f /dev/null
+`, scriptPath)
+		if err := os.MkdirAll(serviceDir, 0755); err != nil {
+			return fmt.Errorf("failed to create s6 service directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(serviceDir, "run"), []byte(runContent), 0755); err != nil {
+			return fmt.Errorf("failed to create s6 run script: %v", err)
+		}
+		scanDir := s6ScanDir()
+		if scanDir == "" {
+			return fmt.Errorf("failed to find s6 scan directory")
+		}
+		link := filepath.Join(scanDir, "spv-autostart")
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			if err := os.Symlink(serviceDir, link); err != nil {
+				return fmt.Errorf("failed to enable s6 service: %v", err)
+			}
+		}
+		if err := exec.Command("s6-svscanctl", "-a", scanDir).Run(); err != nil {
+			return fmt.Errorf("failed to rescan s6 services: %v", err)
+		}
+		return nil
+	case "dinit":
+		serviceContent := fmt.Sprintf(`# SPV Screen Session Autostart
+type = scripted
+command = %s
+restart = false
+`, scriptPath)
+		servicePath := "/etc/dinit.d/spv-autostart"
+		if err := os.WriteFile(servicePath, []byte(serviceContent), 0644); err != nil {
+			return fmt.Errorf("failed to create dinit service: %v", err)
+		}
+		output, err := exec.Command("dinitctl", "enable", "spv-autostart").CombinedOutput()
+		if err != nil && !strings.Contains(string(output), "already") {
+			return fmt.Errorf("failed to enable dinit service: %v", err)
+		}
+		return nil
+	case "cron":
+		crontab, err := readCrontab()
+		if err != nil {
+			return err
+		}
+		crontab = stripCronEntry(crontab)
+		if crontab != "" {
+			crontab += "\n"
+		}
+		crontab += fmt.Sprintf("@reboot %s %s\n", scriptPath, cronMarker)
+		return writeCrontab(crontab)
 	default:
-		return fmt.Errorf("unsupported init system for autostart: %s", sysInfo.InitSystem)
+		return fmt.Errorf("unsupported init system for autostart: %s", sysInfo.AutostartBackend)
 	}
 }
 
 func removeLinuxAutostart(sysInfo SystemInfo) error {
-	scriptPath := "/usr/local/bin/spv-autostart.sh"
+	scriptPath := autostartScriptPath(sysInfo.AutostartBackend)
 
-	switch sysInfo.InitSystem {
+	switch sysInfo.AutostartBackend {
 	case "systemd":
 		servicePath := "/etc/systemd/system/spv-autostart.service"
 		exec.Command("systemctl", "stop", "spv-autostart.service").Run()
@@ -630,106 +1126,147 @@ func removeLinuxAutostart(sysInfo SystemInfo) error {
 		os.Remove(rcScriptPath)
 		os.Remove(scriptPath)
 		return nil
-	default:
-		return fmt.Errorf("unsupported init system for autostart removal: %s", sysInfo.InitSystem)
-	}
-}
-
-func updateAutostartScript(sessions []screenSession) error {
-	sysInfo := detectSystem()
-
-	if sysInfo.OS == "darwin" || sysInfo.OS == "windows" {
-		return fmt.Errorf("autostart is not supported on your OS")
-	}
-
-	autostartSessions := []SessionEntry{}
-	sessionEntries, _ := readConfig(sessionFile)
-	sessionMap := make(map[string]SessionEntry)
-	for _, entry := range sessionEntries {
-		sessionMap[entry.Name] = entry
-	}
-
-	for _, session := range sessions {
-		if session.autostart {
-			if entry, ok := sessionMap[session.name]; ok {
-				autostartSessions = append(autostartSessions, SessionEntry{
-					Name:        session.name,
-					Command:     session.command,
-					Description: session.description,
-					Cwd:         entry.Cwd,
-				})
+	case "runit":
+		if linkDir := runitServiceDir(); linkDir != "" {
+			exec.Command("sv", "down", "spv-autostart").Run()
+			os.Remove(filepath.Join(linkDir, "spv-autostart"))
+		}
+		os.RemoveAll("/etc/sv/spv-autostart")
+		os.Remove(scriptPath)
+		return nil
+	case "s6":
+		if scanDir := s6ScanDir(); scanDir != "" {
+			os.Remove(filepath.Join(scanDir, "spv-autostart"))
+			exec.Command("s6-svscanctl", "-an", scanDir).Run()
+		}
+		os.RemoveAll("/etc/s6/sv/spv-autostart")
+		os.Remove(scriptPath)
+		return nil
+	case "dinit":
+		exec.Command("dinitctl", "disable", "spv-autostart").Run()
+		os.Remove("/etc/dinit.d/spv-autostart")
+		os.Remove(scriptPath)
+		return nil
+	case "cron":
+		crontab, err := readCrontab()
+		if err != nil {
+			return err
+		}
+		if stripped := stripCronEntry(crontab); stripped != strings.TrimRight(crontab, "\n") {
+			if stripped != "" {
+				stripped += "\n"
+			}
+			if err := writeCrontab(stripped); err != nil {
+				return err
 			}
 		}
-	}
-
-	if len(autostartSessions) == 0 {
-		return removeLinuxAutostart(sysInfo)
-	} else {
-		return createLinuxAutostart(autostartSessions, sysInfo)
+		os.Remove(scriptPath)
+		return nil
+	default:
+		return fmt.Errorf("unsupported init system for autostart removal: %s", sysInfo.AutostartBackend)
 	}
 }
 
-func toggleSessionAutostart(sessionName string) error {
-	autostartEntries, err := readConfig(autostartFile)
+// updateAutostartScript installs or removes the boot script to match the
+// store. It returns which backend was used and why, and removes the previous
+// backend's files when the backend changed since the last install.
+func updateAutostartScript() (string, error) {
+	sysInfo, err := detectSystem()
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if sysInfo.OS == "darwin" || sysInfo.OS == "windows" {
+		return "", fmt.Errorf("autostart is not supported on your OS")
 	}
 
-	sessionEntries, err := readConfig(sessionFile)
+	store, err := loadStore()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	var sessionEntry *SessionEntry
-	for _, entry := range sessionEntries {
-		if entry.Name == sessionName {
-			sessionEntry = &entry
-			break
+	autostartSessions := []SessionEntry{}
+	for _, entry := range store.Sessions {
+		if entry.Autostart {
+			autostartSessions = append(autostartSessions, entry)
 		}
 	}
 
-	if sessionEntry == nil {
-		return fmt.Errorf("session not found")
+	summary := fmt.Sprintf("using %s (%s)", sysInfo.AutostartBackend, sysInfo.AutostartReason)
+	if previous := installedAutostartBackend(); previous != "" && previous != sysInfo.AutostartBackend {
+		old := sysInfo
+		old.AutostartBackend = previous
+		if err := removeLinuxAutostart(old); err != nil {
+			return "", fmt.Errorf("failed to remove the previous %s autostart: %v", previous, err)
+		}
+		summary += fmt.Sprintf(", removed the previous %s autostart", previous)
 	}
 
-	var updatedEntries []SessionEntry
-	found := false
-	for _, entry := range autostartEntries {
-		if entry.Name != sessionName {
-			updatedEntries = append(updatedEntries, entry)
-		} else {
-			found = true
+	if len(autostartSessions) == 0 {
+		if err := removeLinuxAutostart(sysInfo); err != nil {
+			return "", err
 		}
+		os.Remove(autostartBackendFile)
+		return summary, nil
 	}
-
-	if !found {
-		updatedEntries = append(updatedEntries, *sessionEntry)
+	if err := createLinuxAutostart(autostartSessions, sysInfo); err != nil {
+		return "", err
 	}
+	if err := writeFileAtomic(autostartBackendFile, []byte(sysInfo.AutostartBackend+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to record the autostart backend: %v", err)
+	}
+	return summary, nil
+}
 
-	if err := writeConfig(autostartFile, updatedEntries); err != nil {
-		return fmt.Errorf("failed to update autostart configuration file: %v", err)
+func setSessionAutostart(sessionName string, on bool) (string, error) {
+	err := updateStore(func(store *Store) error {
+		entry := store.find(sessionName)
+		if entry == nil {
+			return fmt.Errorf("session not found")
+		}
+		entry.Autostart = on
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to update session store: %v", err)
 	}
 
-	updatedManagedSessions := getScreens()
-	return updateAutostartScript(updatedManagedSessions)
+	return updateAutostartScript()
 }
 
-func getScreens() []screenSession {
-	cmd := exec.Command("screen", "-ls")
-	outputBytes, err := cmd.CombinedOutput()
+type bootReport struct {
+	status string
+	detail string
+}
 
-	sessionEntries, _ := readConfig(sessionFile)
-	sessionMap := make(map[string]SessionEntry)
-	for _, entry := range sessionEntries {
-		sessionMap[entry.Name] = entry
+// readAutostartStatus parses the lines the autostart script reports, which
+// are "<unix time>\t<session>\t<status>\t<detail>".
+func readAutostartStatus() map[string]bootReport {
+	statuses := make(map[string]bootReport)
+	data, err := os.ReadFile(autostartStatusFile)
+	if err != nil {
+		return statuses
 	}
+	return parseAutostartStatus(string(data))
+}
 
-	autostartEntries, _ := readConfig(autostartFile)
-	autostartMap := make(map[string]bool)
-	for _, entry := range autostartEntries {
-		autostartMap[entry.Name] = true
+func parseAutostartStatus(data string) map[string]bootReport {
+	statuses := make(map[string]bootReport)
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.SplitN(line, "\t", 4)
+		if len(parts) < 3 || parts[1] == "" {
+			continue
+		}
+		status := bootReport{status: parts[2]}
+		if len(parts) == 4 {
+			status.detail = parts[3]
+		}
+		statuses[parts[1]] = status
 	}
+	return statuses
+}
 
+func parseScreenList(outputBytes []byte, err error) []screenSession {
 	var sessions []screenSession
 
 	if err == nil || strings.Contains(strings.ToLower(string(outputBytes)), "socket") {
@@ -751,9 +1288,7 @@ func getScreens() []screenSession {
 						name = strings.Join(nameParts[1:], ".")
 					}
 
-					if !strings.HasPrefix(name, "spv_") {
-						continue
-					}
+					foreign := !strings.HasPrefix(name, "spv_")
 					displayName := strings.TrimPrefix(name, "spv_")
 
 					status := "unknown"
@@ -769,14 +1304,12 @@ func getScreens() []screenSession {
 						status:      status,
 						command:     "shell",
 						description: "A standard interactive shell session.",
-						autostart:   autostartMap[displayName],
+						foreign:     foreign,
 					}
-
-					if entry, ok := sessionMap[displayName]; ok {
-						session.command = entry.Command
-						session.description = entry.Description
+					if foreign {
+						session.command = ""
+						session.description = "Started outside spv."
 					}
-
 					sessions = append(sessions, session)
 				}
 			}
@@ -786,6 +1319,74 @@ func getScreens() []screenSession {
 	return sessions
 }
 
+func getScreens() ([]screenSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), screenTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "screen", "-ls")
+	cmd.WaitDelay = time.Second
+	outputBytes, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("screen -ls did not answer within %s", screenTimeout)
+	}
+
+	store, storeErr := loadStore()
+	if storeErr != nil {
+		return nil, fmt.Errorf("failed to read session store: %v", storeErr)
+	}
+	sessionMap := make(map[string]SessionEntry)
+	for _, entry := range store.Sessions {
+		sessionMap[entry.Name] = entry
+	}
+
+	bootStatuses := readAutostartStatus()
+
+	sessions := parseScreenList(outputBytes, err)
+	for i := range sessions {
+		session := &sessions[i]
+		session.started = screenStartTime(session.id)
+		if session.foreign {
+			continue
+		}
+		session.bootStatus = bootStatuses[session.name].status
+		session.bootDetail = bootStatuses[session.name].detail
+
+		if entry, ok := sessionMap[session.name]; ok {
+			session.command = entry.Command
+			session.description = entry.Description
+			session.dependsOn = entry.DependsOn
+			session.autostart = entry.Autostart
+			session.createdAt = entry.CreatedAt
+			session.exitCode, session.exitCodeAt = readExitCode(entry.exitFile())
+			delete(sessionMap, session.name)
+		} else {
+			session.exitCode, session.exitCodeAt = readExitCode(exitCodeFile(session.name))
+		}
+	}
+
+	for _, entry := range store.Sessions {
+		if _, ok := sessionMap[entry.Name]; !ok {
+			continue
+		}
+		session := screenSession{
+			name:        entry.Name,
+			status:      "exited",
+			autostart:   entry.Autostart,
+			command:     entry.Command,
+			description: entry.Description,
+			dependsOn:   entry.DependsOn,
+			bootStatus:  bootStatuses[entry.Name].status,
+			bootDetail:  bootStatuses[entry.Name].detail,
+			createdAt:   entry.CreatedAt,
+			lastSeen:    entry.LastSeen,
+			exitedAt:    entry.ExitedAt,
+		}
+		session.exitCode, session.exitCodeAt = readExitCode(entry.exitFile())
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
 func getSystemStats() (float64, float64) {
 	cpuPercent, _ := cpu.Percent(0, false)
 	memStat, _ := mem.VirtualMemory()
@@ -798,24 +1399,198 @@ func getSystemStats() (float64, float64) {
 	return cpuUsage, memStat.UsedPercent
 }
 
-func createScreenSession(name, command, description, cwd string) error {
-	fullSessionName := fmt.Sprintf("spv_%s", name)
+func startScreenSession(entry SessionEntry) error {
+	fullSessionName := fmt.Sprintf("spv_%s", entry.Name)
 
-	var cmdArgs []string
-	if command == "shell" || command == "" {
-		cmdArgs = []string{"-dmS", fullSessionName, "bash", "-c", fmt.Sprintf("cd %q; exec bash", cwd)}
+	if err := os.MkdirAll(logsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.exitFile()), 0755); err != nil {
+		return fmt.Errorf("failed to create exit status directory: %v", err)
+	}
+	os.Remove(entry.exitFile())
+	cmdArgs := screenLogArgs(entry)
+	if entry.Command == "shell" || entry.Command == "" {
+		cmdArgs = append(cmdArgs, "-dmS", fullSessionName, "bash", "-c", fmt.Sprintf("cd %s; exec bash", shellQuote(entry.Cwd)))
 	} else {
-		cmdArgs = []string{"-dmS", fullSessionName, "bash", "-c", fmt.Sprintf("cd %q && %s; exec bash", cwd, command)}
+		cmdArgs = append(cmdArgs, "-dmS", fullSessionName, "bash", "-c", fmt.Sprintf("cd %s && %s; echo $? > %s; exec bash", shellQuote(entry.Cwd), entry.Command, shellQuote(entry.exitFile())))
 	}
 
 	cmd := exec.Command("screen", cmdArgs...)
-	cmd.Dir = cwd
+	cmd.Dir = entry.Cwd
 
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("failed to create screen session: %v", err)
 	}
-
-	return addSessionEntry(name, command, description, cwd)
+	go cmd.Wait()
+	return nil
+}
+
+func createScreenSession(name, command, description, cwd string) error {
+	entry, err := addSessionEntry(SessionEntry{Name: name, Command: command, Description: description, Cwd: cwd})
+	if err != nil {
+		return err
+	}
+	if err := startScreenSession(entry); err != nil {
+		if removeErr := removeSessionEntry(name); removeErr != nil {
+			return fmt.Errorf("%v (and failed to remove it from the store: %v)", err, removeErr)
+		}
+		return err
+	}
+	fireHooks("created", entry)
+	return nil
+}
+
+func screenRunning(name string) bool {
+	sessions, _ := getScreens()
+	for _, session := range sessions {
+		if session.name == name && session.status != "exited" && !session.foreign {
+			return true
+		}
+	}
+	return false
+}
+
+func killSession(name string) error {
+	fullScreenName := fmt.Sprintf("spv_%s", name)
+	expectExit(name)
+	quitErr := exec.Command("screen", "-S", fullScreenName, "-X", "quit").Run()
+
+	store, _ := loadStore()
+	killed := SessionEntry{Name: name}
+	if entry := store.find(name); entry != nil {
+		killed = *entry
+	}
+	wasAutostart := killed.Autostart
+	if err := removeSessionEntry(name); err != nil {
+		return fmt.Errorf("failed to remove session %s from store: %v", name, err)
+	}
+	if quitErr != nil && screenRunning(name) {
+		return fmt.Errorf("failed to quit screen session %s: %v", name, quitErr)
+	}
+	fireHooks("killed", killed)
+	if wasAutostart {
+		if _, err := updateAutostartScript(); err != nil {
+			return fmt.Errorf("failed to update autostart script: %v", err)
+		}
+	}
+	return nil
+}
+
+func sendToSession(name, text string) error {
+	fullScreenName := fmt.Sprintf("spv_%s", name)
+	output, err := exec.Command("screen", "-S", fullScreenName, "-X", "stuff", text).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to send to session %s: %v: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func restartSession(name string) error {
+	store, err := loadStore()
+	if err != nil {
+		return err
+	}
+	entry := store.find(name)
+	if entry == nil {
+		return fmt.Errorf("session %s not found", name)
+	}
+
+	fullScreenName := fmt.Sprintf("spv_%s", name)
+	expectExit(name)
+	exec.Command("screen", "-S", fullScreenName, "-X", "quit").Run()
+	for i := 0; i < 50 && screenRunning(name); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if screenRunning(name) {
+		return fmt.Errorf("session %s did not stop", name)
+	}
+	if err := startScreenSession(*entry); err != nil {
+		return err
+	}
+	fireHooks("restarted", *entry)
+	return updateStore(func(store *Store) error {
+		if entry := store.find(name); entry != nil {
+			entry.Restarts++
+			entry.StartedAt = time.Now()
+			entry.ExitedAt = time.Time{}
+		}
+		return nil
+	})
+}
+
+func renameSession(name, newName string) error {
+	if newName == "" || strings.ContainsAny(newName, ". \t") {
+		return fmt.Errorf("invalid session name %q", newName)
+	}
+	running := screenRunning(name)
+	var renamed SessionEntry
+	err := updateStore(func(store *Store) error {
+		entry := store.find(name)
+		if entry == nil {
+			return fmt.Errorf("session %s not found", name)
+		}
+		if store.find(newName) != nil {
+			return fmt.Errorf("a session named %s already exists", newName)
+		}
+		entry.ExitFile = entry.exitFile()
+		entry.Name = newName
+		renameDependency(store, name, newName)
+		renamed = *entry
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The screen is renamed only once the store has the new name, and the
+	// store is put back if screen refuses.
+	if running {
+		output, err := exec.Command("screen", "-S", "spv_"+name, "-X", "sessionname", "spv_"+newName).CombinedOutput()
+		if err != nil {
+			err = fmt.Errorf("failed to rename screen session %s: %v: %s", name, err, strings.TrimSpace(string(output)))
+			if undoErr := updateStore(func(store *Store) error {
+				if entry := store.find(newName); entry != nil {
+					entry.Name = name
+					renameDependency(store, newName, name)
+				}
+				return nil
+			}); undoErr != nil {
+				err = fmt.Errorf("%v; and failed to restore %s in the store: %v", err, name, undoErr)
+			}
+			return err
+		}
+	}
+
+	var errs []string
+	if err := os.Rename(sessionLogFile(name), sessionLogFile(newName)); err != nil && !os.IsNotExist(err) {
+		errs = append(errs, fmt.Sprintf("failed to move log: %v", err))
+	}
+	if running {
+		output, err := exec.Command("screen", "-S", "spv_"+newName, "-X", "logfile", sessionLogFile(newName)).CombinedOutput()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to point screen at the new log: %v: %s", err, strings.TrimSpace(string(output))))
+		}
+	}
+	if renamed.Autostart {
+		if _, err := updateAutostartScript(); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to update autostart script: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("renamed %s to %s, but %s", name, newName, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func renameDependency(store *Store, name, newName string) {
+	for i := range store.Sessions {
+		for j, dep := range store.Sessions[i].DependsOn {
+			if dep == name {
+				store.Sessions[i].DependsOn[j] = newName
+			}
+		}
+	}
 }
 
 func fetchLatestCommit() tea.Msg {
@@ -853,6 +1628,9 @@ func fetchLatestCommit() tea.Msg {
 func (m model) Init() tea.Cmd {
 	return tea.Batch(
 		textinput.Blink,
+		refreshCmd(m.backend, m.runsLocally(), m.refreshGen),
+		pollCmd(m.pollEvery, m.hostGen),
+		waitForChange(m.changes),
 		tea.Tick(time.Second, func(t time.Time) tea.Msg {
 			return tickMsg(t)
 		}),
@@ -860,23 +1638,98 @@ func (m model) Init() tea.Cmd {
 	)
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tickMsg:
-		if m.state == listView {
-			m.cpuUsage, m.memUsage = getSystemStats()
-			m.sessions = getScreens()
-			if m.selected >= len(m.sessions) && len(m.sessions) > 0 {
-				m.selected = len(m.sessions) - 1
-			} else if len(m.sessions) == 0 {
-				m.selected = 0
+		cmds := []tea.Cmd{tea.Tick(time.Second, func(t time.Time) tea.Msg {
+			return tickMsg(t)
+		})}
+		if !m.scanning {
+			m.scanning = true
+			cmds = append(cmds, m.watcher.scanCmd())
+		}
+		if m.state == viewingLogs {
+			cmds = append(cmds, m.refreshLogView())
+		}
+		if m.state == showingHistory {
+			cmds = append(cmds, m.refreshHistoryView())
+		}
+		if m.changes != nil && !m.statsLoading {
+			m.statsLoading = true
+			cmds = append(cmds, statsCmd())
+		}
+		return m, tea.Batch(cmds...)
+
+	case alertsMsg:
+		m.scanning = false
+		for _, alert := range msg.alerts {
+			if current, ok := m.alerts[alert.session]; !ok || severityRank(alert.severity) >= severityRank(current.severity) {
+				m.alerts[alert.session] = alert
 			}
+			m.notify(alertLevel(alert.severity), fmt.Sprintf("%s: %s in %s", alert.severity, alert.line, alert.session))
 		}
-		return m, tea.Tick(time.Second, func(t time.Time) tea.Msg {
-			return tickMsg(t)
-		})
+		return m, nil
+
+	case statsMsg:
+		m.statsLoading = false
+		if msg.ok {
+			m.cpuUsage, m.memUsage = msg.cpu, msg.mem
+		}
+		return m, nil
+
+	case historyMsg:
+		m.applyHistory(msg)
+		return m, nil
+
+	case inspectMsg:
+		if msg.id != m.adopting.id || msg.command == "" {
+			return m, nil
+		}
+		m.adoptCommand = msg.command
+		if m.state == adoptingCommand && m.textInput.Value() == "" {
+			m.textInput.SetValue(msg.command)
+		}
+		return m, nil
+
+	case pollMsg:
+		if msg.gen != m.hostGen {
+			return m, nil
+		}
+		if m.state == listView {
+			m.refresh()
+		}
+		return m, pollCmd(m.pollEvery, m.hostGen)
+
+	case changeMsg:
+		if msg.closed {
+			m.changes = nil
+			m.pollEvery = m.pollInterval()
+			return m, nil
+		}
+		m.refresh()
+		return m, waitForChange(m.changes)
+
+	case refreshMsg:
+		m.applyRefresh(msg)
+		return m, nil
+
+	case actionMsg:
+		if msg.err != nil {
+			m.notify(noticeError, msg.err.Error())
+		} else if msg.done != "" {
+			m.notify(noticeInfo, msg.done)
+		}
+		m.refresh()
+		return m, nil
+
+	case hostMsg:
+		return m, m.applyHost(msg)
+
+	case logMsg:
+		m.applyLog(msg)
+		return m, nil
 
 	case commitMsg:
 		m.commitMsg = string(msg)
@@ -885,130 +1738,221 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		if m.state == viewingLogs || m.state == searchingLogs {
+			m.showLog()
+		}
+		if m.state == showingHistory {
+			m.showHistory()
+		}
+		if m.state == showingNotices {
+			m.refreshNoticeView()
+		}
 
-	case clearErrorMsg:
-		m.errorMsg = ""
+	case expireNoticeMsg:
+		m.expireNotice(msg.id)
 		return m, nil
 
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
 	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
 		switch m.state {
 		case listView:
-			switch msg.String() {
-			case "q", "ctrl+c":
-				return m, tea.Quit
-
-			case "up":
-				if len(m.sessions) > 0 && m.selected > 0 {
-					m.selected--
-				}
-
-			case "down":
-				if len(m.sessions) > 0 && m.selected < len(m.sessions)-1 {
-					m.selected++
+			for _, action := range m.keys.actions() {
+				if key.Matches(msg, *action.binding) {
+					return m.runAction(action.name)
 				}
+			}
 
-			case "a":
-				m.state = addingName
-				m.textInput.Placeholder = "Enter session name"
-				m.textInput.Focus()
-				return m, textinput.Blink
+		case showingPalette:
+			if model, cmd, handled := m.updatePalette(msg); handled {
+				return model, cmd
+			}
 
-			case "k":
-				if len(m.sessions) > 0 && m.selected < len(m.sessions) {
-					session := m.sessions[m.selected]
-					fullScreenName := fmt.Sprintf("spv_%s", session.name)
+		case selectingTheme:
+			return m.updateThemePicker(msg)
 
-					exec.Command("screen", "-S", fullScreenName, "-X", "quit").Run()
+		case showingHelp:
+			if key.Matches(msg, m.keys.Help, m.keys.Quit, m.keys.Back) {
+				m.state = listView
+			}
 
-					removeEntry(sessionFile, session.name)
-					removeEntry(autostartFile, session.name)
+		case showingAbout:
+			m.state = listView
 
-					m.sessions = getScreens()
-					if m.selected >= len(m.sessions) && len(m.sessions) > 0 {
-						m.selected = len(m.sessions) - 1
-					} else if len(m.sessions) == 0 {
-						m.selected = 0
-					}
+		case viewingLogs:
+			switch {
+			case key.Matches(msg, m.keys.Back):
+				m.state = listView
+			case key.Matches(msg, m.keys.Search):
+				m.state = searchingLogs
+				m.textInput.Placeholder = "Search logs"
+				m.textInput.SetValue(m.logQuery)
+				m.textInput.Focus()
+				return m, textinput.Blink
+			case key.Matches(msg, m.keys.NextMatch):
+				m.jumpToLogMatch(1)
+			case key.Matches(msg, m.keys.PrevMatch):
+				m.jumpToLogMatch(-1)
+			case key.Matches(msg, m.keys.PaneUp):
+				m.logViewport.ScrollUp(1)
+			case key.Matches(msg, m.keys.PaneDown):
+				m.logViewport.ScrollDown(1)
+			case key.Matches(msg, m.keys.PaneTop):
+				m.logViewport.GotoTop()
+			case key.Matches(msg, m.keys.PaneBottom):
+				m.logViewport.GotoBottom()
+			default:
+				m.logViewport, cmd = m.logViewport.Update(msg)
+				return m, cmd
+			}
+			return m, nil
 
-					if err := updateAutostartScript(m.sessions); err != nil {
-						m.errorMsg = "Issues creating autostart script"
-						go func() {
-							time.Sleep(3 * time.Second)
-							p.Send(clearErrorMsg{})
-						}()
-					}
+		case adoptingName:
+			switch msg.String() {
+			case "enter":
+				m.tempName = strings.TrimSpace(m.textInput.Value())
+				if m.tempName == "" {
+					return m, nil
 				}
+				m.state = adoptingCommand
+				m.textInput.Placeholder = "Command it runs (blank for shell)"
+				m.textInput.SetValue(m.adoptCommand)
+				return m, textinput.Blink
 
-			case "r":
-				m.cpuUsage, m.memUsage = getSystemStats()
-				m.sessions = getScreens()
+			case "esc":
+				m.state = listView
+				m.textInput.Blur()
+				m.textInput.SetValue("")
+			}
 
+		case adoptingCommand:
+			switch msg.String() {
 			case "enter":
-				if len(m.sessions) > 0 && m.selected < len(m.sessions) {
-					session := m.sessions[m.selected]
-					fullScreenName := fmt.Sprintf("%s.spv_%s", session.id, session.name)
-					return m, tea.ExecProcess(
-						exec.Command("screen", "-r", fullScreenName),
-						nil,
-					)
-				}
+				session, name, command := m.adopting, m.tempName, m.textInput.Value()
+				m.state = listView
+				m.textInput.Blur()
+				m.textInput.SetValue("")
+				backend := m.backendFor(session)
+				return m, actionCmd(func() error {
+					return backend.adopt(session, name, command)
+				}, "")
 
-			case "t":
-				if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
-					m.errorMsg = "Autostart is not supported on macOS/Windows"
-					go func() {
-						time.Sleep(3 * time.Second)
-						p.Send(clearErrorMsg{})
-					}()
-					return m, nil
+			case "esc":
+				m.state = listView
+				m.textInput.Blur()
+				m.textInput.SetValue("")
+			}
+
+		case filteringSessions:
+			switch msg.String() {
+			case "enter", "esc":
+				if msg.String() == "esc" {
+					m.textInput.SetValue("")
 				}
+				m.filter = m.textInput.Value()
+				m.textInput.SetValue("")
+				m.textInput.Blur()
+				m.state = listView
+				m.selected = 0
+				m.refresh()
+				return m, nil
+			}
 
-				if len(m.sessions) > 0 && m.selected < len(m.sessions) {
-					session := m.sessions[m.selected]
-					if err := toggleSessionAutostart(session.name); err != nil {
-						m.errorMsg = "Issues creating autostart script"
-						go func() {
-							time.Sleep(3 * time.Second)
-							p.Send(clearErrorMsg{})
-						}()
-					}
-					m.sessions = getScreens()
+		case selectingHost:
+			choices := hostChoices()
+			switch {
+			case key.Matches(msg, m.keys.PaneUp):
+				if m.hostCursor > 0 {
+					m.hostCursor--
+				}
+			case key.Matches(msg, m.keys.PaneDown):
+				if m.hostCursor < len(choices)-1 {
+					m.hostCursor++
 				}
+			case key.Matches(msg, m.keys.Confirm):
+				m.state = listView
+				if m.hostCursor < len(choices) {
+					return m, m.selectHost(choices[m.hostCursor])
+				}
+			case key.Matches(msg, m.keys.Back):
+				m.state = listView
+			}
+			return m, nil
 
-			case "?":
-				m.state = showingAbout
+		case showingHistory:
+			switch {
+			case key.Matches(msg, m.keys.Back, m.keys.History):
+				m.state = listView
+			case key.Matches(msg, m.keys.PaneUp):
+				m.historyViewport.ScrollUp(1)
+			case key.Matches(msg, m.keys.PaneDown):
+				m.historyViewport.ScrollDown(1)
+			case key.Matches(msg, m.keys.PaneTop):
+				m.historyViewport.GotoTop()
+			case key.Matches(msg, m.keys.PaneBottom):
+				m.historyViewport.GotoBottom()
+			default:
+				m.historyViewport, cmd = m.historyViewport.Update(msg)
+				return m, cmd
 			}
+			return m, nil
 
-		case showingAbout:
-			m.state = listView
+		case showingNotices:
+			switch {
+			case key.Matches(msg, m.keys.Back, m.keys.Notices):
+				m.state = listView
+			case key.Matches(msg, m.keys.PaneUp):
+				m.noticeViewport.ScrollUp(1)
+			case key.Matches(msg, m.keys.PaneDown):
+				m.noticeViewport.ScrollDown(1)
+			case key.Matches(msg, m.keys.PaneTop):
+				m.noticeViewport.GotoTop()
+			case key.Matches(msg, m.keys.PaneBottom):
+				m.noticeViewport.GotoBottom()
+			default:
+				m.noticeViewport, cmd = m.noticeViewport.Update(msg)
+				return m, cmd
+			}
+			return m, nil
+
+		case searchingLogs:
+			switch msg.String() {
+			case "enter":
+				m.logQuery = m.textInput.Value()
+				m.textInput.SetValue("")
+				m.textInput.Blur()
+				m.state = viewingLogs
+				m.showLog()
+				m.logMatch = -1
+				m.jumpToLogMatch(1)
+				return m, nil
+			case "esc":
+				m.textInput.SetValue("")
+				m.textInput.Blur()
+				m.state = viewingLogs
+				return m, nil
+			}
 
 		case addingName:
 			switch msg.String() {
 			case "enter":
 				m.tempName = m.textInput.Value()
 				m.textInput.SetValue("")
-				cwd, err := os.Getwd()
+				cwd, err := m.createCwd()
 				if err != nil {
-					m.errorMsg = fmt.Sprintf("Error getting current directory: %v", err)
+					m.notify(noticeError, fmt.Sprintf("Error getting current directory: %v", err))
 					cwd, _ = os.UserHomeDir()
-					go func() {
-						time.Sleep(3 * time.Second)
-						p.Send(clearErrorMsg{})
-					}()
 				}
 
 				if m.tempName == "" {
 					exec.Command("screen").Start()
-					if err := createScreenSession(m.tempName, "shell", "A standard interactive shell session.", cwd); err != nil {
-						m.errorMsg = "Issues creating screen session"
-						go func() {
-							time.Sleep(3 * time.Second)
-							p.Send(clearErrorMsg{})
-						}()
-					}
 					m.state = listView
 					m.textInput.Blur()
-					m.sessions = getScreens()
+					return m, m.createCmd(SessionEntry{Name: m.tempName, Command: "shell", Description: "A standard interactive shell session.", Cwd: cwd})
 				} else {
 					m.state = addingCommand
 					m.textInput.Placeholder = "Enter command (blank for shell)"
@@ -1026,29 +1970,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				m.tempCommand = m.textInput.Value()
 				m.textInput.SetValue("")
-				cwd, err := os.Getwd()
+				cwd, err := m.createCwd()
 				if err != nil {
-					m.errorMsg = fmt.Sprintf("Error getting current directory: %v", err)
+					m.notify(noticeError, fmt.Sprintf("Error getting current directory: %v", err))
 					cwd, _ = os.UserHomeDir()
-					go func() {
-						time.Sleep(3 * time.Second)
-						p.Send(clearErrorMsg{})
-					}()
 				}
 
 				if m.tempCommand == "" {
 					m.tempCommand = "shell"
 					m.tempDescription = "A standard interactive shell session."
-					if err := createScreenSession(m.tempName, m.tempCommand, m.tempDescription, cwd); err != nil {
-						m.errorMsg = "Issues creating screen session"
-						go func() {
-							time.Sleep(3 * time.Second)
-							p.Send(clearErrorMsg{})
-						}()
-					}
 					m.state = listView
 					m.textInput.Blur()
-					m.sessions = getScreens()
+					return m, m.createCmd(SessionEntry{Name: m.tempName, Command: m.tempCommand, Description: m.tempDescription, Cwd: cwd})
 				} else {
 					m.state = addingDescription
 					m.textInput.Placeholder = "Enter description (optional)"
@@ -1069,28 +2002,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.tempDescription = "A screen session running a custom command."
 				}
 
-				cwd, err := os.Getwd()
+				cwd, err := m.createCwd()
 				if err != nil {
-					m.errorMsg = fmt.Sprintf("Error getting current directory: %v", err)
+					m.notify(noticeError, fmt.Sprintf("Error getting current directory: %v", err))
 					cwd, _ = os.UserHomeDir()
-					go func() {
-						time.Sleep(3 * time.Second)
-						p.Send(clearErrorMsg{})
-					}()
-				}
-
-				if err := createScreenSession(m.tempName, m.tempCommand, m.tempDescription, cwd); err != nil {
-					m.errorMsg = "Issues creating screen session"
-					go func() {
-						time.Sleep(3 * time.Second)
-						p.Send(clearErrorMsg{})
-					}()
 				}
 
 				m.state = listView
 				m.textInput.Blur()
 				m.textInput.SetValue("")
-				m.sessions = getScreens()
+				return m, m.createCmd(SessionEntry{Name: m.tempName, Command: m.tempCommand, Description: m.tempDescription, Cwd: cwd})
 
 			case "esc":
 				m.state = listView
@@ -1101,15 +2022,335 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch m.state {
-	case addingName, addingCommand, addingDescription:
+	case addingName, addingCommand, addingDescription, searchingLogs, filteringSessions, adoptingName, adoptingCommand, showingPalette:
 		m.textInput, cmd = m.textInput.Update(msg)
 	}
 
 	return m, cmd
 }
 
+func (m model) runAction(action string) (tea.Model, tea.Cmd) {
+	if len(m.sessions) > 0 && m.selected < len(m.sessions) && m.sessions[m.selected].foreign {
+		switch action {
+		case "kill", "autostart", "logs", "restart":
+			m.notify(noticeWarn, fmt.Sprintf("%s was not started by spv, press %s to adopt it", m.sessions[m.selected].name, m.keys.Adopt.Help().Key))
+			return m, nil
+		}
+	}
+
+	switch action {
+	case "quit":
+		return m, tea.Quit
+
+	case "up":
+		if len(m.sessions) > 0 && m.selected > 0 {
+			m.selected--
+		}
+
+	case "down":
+		if len(m.sessions) > 0 && m.selected < len(m.sessions)-1 {
+			m.selected++
+		}
+
+	case "add":
+		m.state = addingName
+		m.textInput.Placeholder = "Enter session name"
+		m.textInput.Focus()
+		return m, textinput.Blink
+
+	case "kill":
+		if len(m.sessions) > 0 && m.selected < len(m.sessions) {
+			session := m.sessions[m.selected]
+			backend := m.backendFor(session)
+			return m, actionCmd(func() error {
+				return backend.kill(session.name)
+			}, "")
+		}
+
+	case "refresh":
+		m.refresh()
+
+	case "attach":
+		if len(m.sessions) > 0 && m.selected < len(m.sessions) {
+			session := m.sessions[m.selected]
+			if session.status == "exited" {
+				m.notify(noticeWarn, fmt.Sprintf("Session %s has exited", session.name))
+				return m, nil
+			}
+			return m, tea.ExecProcess(m.backendFor(session).attach(session), nil)
+		}
+
+	case "autostart":
+		if len(m.sessions) > 0 && m.selected < len(m.sessions) && m.sessions[m.selected].host != "" {
+			m.notify(noticeWarn, "Autostart is only available for local sessions")
+			return m, nil
+		}
+		if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+			m.notify(noticeWarn, "Autostart is not supported on macOS/Windows")
+			return m, nil
+		}
+
+		if len(m.sessions) > 0 && m.selected < len(m.sessions) {
+			session := m.sessions[m.selected]
+			backend, on, state := m.backendFor(session), !session.autostart, "on"
+			if session.autostart {
+				state = "off"
+			}
+			return m, func() tea.Msg {
+				summary, err := backend.setAutostart(session.name, on)
+				if err != nil {
+					return actionMsg{err: fmt.Errorf("Failed to update autostart for %s: %v", session.name, err)}
+				}
+				return actionMsg{done: fmt.Sprintf("Autostart %s for %s, %s", state, session.name, summary)}
+			}
+		}
+
+	case "logs":
+		if len(m.sessions) > 0 && m.selected < len(m.sessions) {
+			m.logSession = m.sessions[m.selected].name
+			m.logHost = m.sessions[m.selected].host
+			delete(m.alerts, m.logSession)
+			m.logQuery = ""
+			m.logMatch = 0
+			m.logViewport = viewport.New(0, 0)
+			m.logText, m.logErr = "", nil
+			m.logLoading = false
+			m.state = viewingLogs
+			m.showLog()
+			cmd := m.refreshLogView()
+			m.logViewport.GotoBottom()
+			return m, cmd
+		}
+
+	case "sort":
+		m.sortMode = (m.sortMode + 1) % sortModeCount
+		m.rearrange()
+
+	case "filter":
+		m.state = filteringSessions
+		m.textInput.Placeholder = "Filter sessions"
+		m.textInput.SetValue(m.filter)
+		m.textInput.Focus()
+		return m, textinput.Blink
+
+	case "clear_filter":
+		if m.filter != "" {
+			m.filter = ""
+			m.refresh()
+		}
+
+	case "foreign":
+		m.showForeign = !m.showForeign
+		m.refresh()
+
+	case "adopt":
+		if len(m.sessions) > 0 && m.selected < len(m.sessions) {
+			session := m.sessions[m.selected]
+			if !session.foreign {
+				m.notify(noticeWarn, fmt.Sprintf("%s is already managed by spv", session.name))
+				return m, nil
+			}
+			if session.host != "" {
+				m.notify(noticeWarn, "Adopting is only available for local sessions")
+				return m, nil
+			}
+			m.adopting = session
+			m.adoptCommand = ""
+			m.state = adoptingName
+			m.textInput.Placeholder = "Name for the adopted session"
+			m.textInput.SetValue(session.name)
+			m.textInput.Focus()
+			return m, tea.Batch(textinput.Blink, inspectCmd(session.id))
+		}
+
+	case "hosts":
+		m.hostCursor = 0
+		for i, name := range hostChoices() {
+			if name == m.host {
+				m.hostCursor = i
+			}
+		}
+		m.state = selectingHost
+
+	case "sidebar":
+		m.sidebarHidden = !m.sidebarHidden
+
+	case "notices":
+		m.noticeViewport = viewport.New(0, 0)
+		m.state = showingNotices
+		m.refreshNoticeView()
+		m.noticeViewport.GotoBottom()
+
+	case "dismiss":
+		m.dismissNotice()
+
+	case "history":
+		m.historyViewport = viewport.New(0, 0)
+		m.state = showingHistory
+		cmd := m.refreshHistoryView()
+		m.showHistory()
+		m.historyViewport.GotoBottom()
+		return m, cmd
+
+	case "top":
+		m.selected = 0
+
+	case "bottom":
+		if len(m.sessions) > 0 {
+			m.selected = len(m.sessions) - 1
+		}
+
+	case "help":
+		m.state = showingHelp
+
+	case "palette":
+		return m.openPalette()
+
+	case "theme":
+		m.themeBefore = currentTheme
+		m.themeCursor = 0
+		for i, name := range themeNames() {
+			if name == currentTheme {
+				m.themeCursor = i
+			}
+		}
+		m.state = selectingTheme
+
+	case "rename":
+		for _, command := range m.paletteCommands() {
+			if command.name == "rename" {
+				return m.promptPalette(command)
+			}
+		}
+
+	case "restart":
+		if len(m.sessions) > 0 && m.selected < len(m.sessions) {
+			session := m.sessions[m.selected]
+			backend := m.backendFor(session)
+			return m, actionCmd(func() error {
+				return backend.restart(session.name)
+			}, fmt.Sprintf("Restarted %s", session.name))
+		}
+
+	case "about":
+		m.state = showingAbout
+	}
+	return m, nil
+}
+
+func (m model) headerView(l layout, versionStr string) string {
+	headerStatus := m.staleness() + m.hostHeader()
+	headerColumn := l.width - 6 - lipgloss.Width(headerStatus)
+	if headerColumn < 0 {
+		headerColumn = 0
+	}
+	return headerStyle.Width(l.width - 2).Render(
+		lipgloss.JoinHorizontal(
+			lipgloss.Left,
+			lipgloss.NewStyle().Width(headerColumn).MaxWidth(headerColumn).MaxHeight(1).Render(versionStr),
+			headerStatus,
+		),
+	)
+}
+
+// detailsText renders the details pane for the selected session at the
+// pane's inner width, before it is scrolled and clipped to the pane.
+func (m model) detailsText(l layout) string {
+	var content strings.Builder
+	if len(m.sessions) > 0 && m.selected < len(m.sessions) {
+		session := m.sessions[m.selected]
+
+		content.WriteString(titleStyle.Render(session.name) + "\n")
+
+		statusStyle := statusDetachedStyle
+		statusText := "detached"
+		if session.status == "attached" {
+			statusStyle = statusAttachedStyle
+			statusText = "attached"
+		} else if session.status == "exited" && session.bootStatus == "skipped" && session.lastSeen.IsZero() {
+			statusText = "not started"
+		} else if session.status == "exited" {
+			statusStyle = mutedTextStyle
+			statusText = "exited"
+		}
+		content.WriteString(statusStyle.Render(statusText) + "\n\n")
+
+		if session.host != "" {
+			content.WriteString(accentStyle.Render("Host: ") + session.host + "\n")
+		}
+		if session.foreign {
+			content.WriteString(mutedTextStyle.Render("Not managed by spv • "+m.keys.Adopt.Help().Key+" to adopt") + "\n")
+		}
+
+		if session.status != "exited" {
+			content.WriteString(accentStyle.Render("ID: ") + session.id + "\n")
+			if !session.started.IsZero() {
+				content.WriteString(accentStyle.Render("Uptime: ") + formatDuration(time.Since(session.started)) + "\n")
+			}
+		} else if !session.lastSeen.IsZero() || session.bootStatus != "skipped" {
+			content.WriteString(accentStyle.Render("Last seen: ") + formatTimestamp(session.lastSeen) + "\n")
+			content.WriteString(accentStyle.Render("Exited: ") + formatTimestamp(session.exitedAt) + "\n")
+		}
+		if !session.createdAt.IsZero() {
+			content.WriteString(accentStyle.Render("Created: ") + formatTimestamp(session.createdAt) + "\n")
+		}
+		if session.exitCode != "" {
+			content.WriteString(accentStyle.Render("Command exited: ") + session.exitCode +
+				mutedTextStyle.Render(" at "+formatTimestamp(session.exitCodeAt)) + "\n")
+		}
+		content.WriteString(accentStyle.Render("Autostart: "))
+		if session.autostart {
+			content.WriteString("On\n")
+		} else {
+			content.WriteString("Off\n")
+		}
+		if len(session.dependsOn) > 0 {
+			content.WriteString(accentStyle.Render("Depends on: ") + strings.Join(session.dependsOn, ", ") + "\n")
+		}
+		if session.bootStatus != "" {
+			content.WriteString(accentStyle.Render("Boot: "))
+			switch session.bootStatus {
+			case "timeout":
+				content.WriteString(statusDetachedStyle.Render("timed out waiting for readiness") + "\n")
+			case "skipped":
+				content.WriteString(statusDetachedStyle.Render("skipped, dependency not ready") + "\n")
+			default:
+				content.WriteString(session.bootStatus + "\n")
+			}
+			if session.bootDetail != "" {
+				content.WriteString(mutedTextStyle.Render(session.bootDetail) + "\n")
+			}
+		}
+		content.WriteString("\n")
+
+		if alert, ok := m.alerts[session.name]; ok && session.host == "" {
+			content.WriteString(accentStyle.Render("Alert: ") + alertBadge(alert.severity) + " " +
+				mutedTextStyle.Render(alert.at.Format("15:04:05")) + "\n" + alert.line + "\n\n")
+		}
+
+		content.WriteString(accentStyle.Render("command") + "\n")
+		content.WriteString(mutedTextStyle.Render(session.command) + "\n\n")
+
+		content.WriteString(accentStyle.Render("description") + "\n")
+		content.WriteString(mutedTextStyle.Render(session.description))
+
+	} else {
+		content.WriteString(mutedTextStyle.Render("No session selected") + "\n\n" + normalTextStyle.Render(fmt.Sprintf("Press '%s' to create a new session", m.keys.Add.Help().Key)))
+	}
+
+	if notice := m.noticeView(); notice != "" {
+		content.WriteString("\n\n" + notice)
+	}
+
+	return lipgloss.NewStyle().Width(l.contentWidth - 6).Render(content.String())
+}
+
+func (m model) detailsView(l layout) string {
+	return scrollLines(m.detailsText(l), m.detailsOffset(), l.contentHeight-2)
+}
+
 func (m model) View() string {
-	if m.width == 0 || m.height < 10 {
+	if m.width == 0 || m.height < 15 {
 		return "Initializing or window too small..."
 	}
 
@@ -1125,16 +2366,16 @@ func (m model) View() string {
 				"Minimal TUI for Linux screen management\n\n" +
 				accentStyle.Render("Author:") + "\n" +
 				"Git: " +
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#10B981")).Render("@non-erx") +
+				gitLinkStyle.Render("@non-erx") +
 				"\n" +
 				"Bluesky: " +
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#0EA5E9")).Render("@mean2ya") +
+				blueskyLinkStyle.Render("@mean2ya") +
 				"\n" +
 				"LinkedIn: " +
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#0077B5")).Render("@symonchuk") +
+				linkedInLinkStyle.Render("@symonchuk") +
 				"\n" +
 				"SoundCloud: " +
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5500")).Render("@mean2ya") +
+				soundCloudLinkStyle.Render("@mean2ya") +
 				"\n\n" +
 				(func() string {
 					if m.commitMsg != "" && Commit != m.commitMsg && Commit != "" {
@@ -1145,16 +2386,23 @@ func (m model) View() string {
 					}
 					return ""
 				}()) +
-				lipgloss.NewStyle().Foreground(lipgloss.Color("#64748B")).Render("Press any key to continue..."),
+				mutedTextStyle.Render("Press any key to continue..."),
 		)
 		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, about)
 
-	case addingName, addingCommand, addingDescription:
+	case addingName, addingCommand, addingDescription, filteringSessions, adoptingName, adoptingCommand:
 		prompt := "Session Name"
-		if m.state == addingCommand {
+		switch m.state {
+		case addingCommand:
 			prompt = "Command"
-		} else if m.state == addingDescription {
+		case addingDescription:
 			prompt = "Description (optional)"
+		case filteringSessions:
+			prompt = "Filter"
+		case adoptingName:
+			prompt = "Adopt " + m.adopting.name + " as"
+		case adoptingCommand:
+			prompt = "Command"
 		}
 
 		content := lipgloss.JoinVertical(
@@ -1168,132 +2416,68 @@ func (m model) View() string {
 
 		box := inputStyle.Render(content)
 		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
-	}
-
-	versionStr := Version
-	if Commit != "" {
-		versionStr = fmt.Sprintf("%s (%s)", Version, Commit)
-	} else {
-		if m.commitMsg != "" {
-			versionStr = fmt.Sprintf("%s (%s)", Version, m.commitMsg)
-		}
-	}
-
-	header := headerStyle.Width(80).Render(
-		lipgloss.JoinHorizontal(
-			lipgloss.Left,
-			lipgloss.NewStyle().Width(40).Render(versionStr),
-			lipgloss.NewStyle().Width(40).Render(fmt.Sprintf(
-				"cpu %.1f%% ram %.1f%% [%d]",
-				m.cpuUsage,
-				m.memUsage,
-				len(m.sessions),
-			)),
-		),
-	)
 
-	mainPanelContentHeight := m.height - 10
-	dynamicSidebarStyle := sidebarStyle.Copy().Height(mainPanelContentHeight)
-	dynamicContentStyle := contentStyle.Copy().Height(mainPanelContentHeight)
+	case viewingLogs, searchingLogs:
+		return m.logView()
 
-	var sidebar strings.Builder
-	sidebar.WriteString(accentStyle.Render("sessions") + "\n\n")
+	case showingHistory:
+		return m.historyView()
 
-	listViewportHeight := mainPanelContentHeight - 2
-	if listViewportHeight < 1 {
-		listViewportHeight = 1
-	}
+	case showingNotices:
+		return m.noticeLogView()
 
-	start := 0
-	end := len(m.sessions)
+	case showingHelp:
+		return m.helpView()
 
-	if len(m.sessions) > listViewportHeight {
-		if m.selected >= start+listViewportHeight {
-			start = m.selected - listViewportHeight + 1
-		} else if m.selected < start {
-			start = m.selected
-		}
-		end = start + listViewportHeight
-		if end > len(m.sessions) {
-			end = len(m.sessions)
-		}
-	}
+	case showingPalette:
+		return m.paletteView()
 
-	hasMoreAbove := start > 0
-	hasMoreBelow := end < len(m.sessions)
+	case selectingTheme:
+		m.state = listView
+		return overlay(m.View(), m.themePickerView())
 
-	if hasMoreAbove {
-		sidebar.WriteString(overflowStyle.Render("... ↑ more above") + "\n")
+	case selectingHost:
+		return m.hostPickerView()
 	}
 
-	if len(m.sessions) == 0 {
-		sidebar.WriteString(mutedTextStyle.Render("no active sessions") + "\n")
+	versionStr := Version
+	if Commit != "" {
+		versionStr = fmt.Sprintf("%s (%s)", Version, Commit)
 	} else {
-		for i := start; i < end; i++ {
-			session := m.sessions[i]
-			sessionDisplay := session.name
-			if session.autostart {
-				sessionDisplay += " ●"
-			}
-			if i == m.selected {
-				sidebar.WriteString(selectedStyle.Render(sessionDisplay) + "\n")
-			} else {
-				sidebar.WriteString(sessionDisplay + "\n")
-			}
-		}
-	}
-
-	if hasMoreBelow {
-		sidebar.WriteString(overflowStyle.Render("... ↓ more below"))
-	}
-
-	var content strings.Builder
-	if len(m.sessions) > 0 && m.selected < len(m.sessions) {
-		session := m.sessions[m.selected]
-
-		content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#F1F5F9")).Bold(true).Render(session.name) + "\n")
-
-		statusStyle := statusDetachedStyle
-		statusText := "detached"
-		if session.status == "attached" {
-			statusStyle = statusAttachedStyle
-			statusText = "attached"
-		}
-		content.WriteString(statusStyle.Render(statusText) + "\n\n")
-
-		content.WriteString(accentStyle.Render("ID: ") + session.id + "\n")
-		content.WriteString(accentStyle.Render("Autostart: "))
-		if session.autostart {
-			content.WriteString("On\n\n")
-		} else {
-			content.WriteString("Off\n\n")
+		if m.commitMsg != "" {
+			versionStr = fmt.Sprintf("%s (%s)", Version, m.commitMsg)
 		}
-
-		content.WriteString(accentStyle.Render("command") + "\n")
-		content.WriteString(mutedTextStyle.Render(session.command) + "\n\n")
-
-		content.WriteString(accentStyle.Render("description") + "\n")
-		content.WriteString(mutedTextStyle.Render(session.description))
-
-	} else {
-		content.WriteString(mutedTextStyle.Render("No session selected") + "\n\n" + normalTextStyle.Render("Press 'a' to create a new session"))
 	}
 
-	if m.errorMsg != "" {
-		content.WriteString("\n\n" + errorTextStyle.Render(m.errorMsg))
-		go func() {
-			time.Sleep(3 * time.Second)
-			p.Send(clearErrorMsg{})
-		}()
+	l := m.layout()
+	header := m.headerView(l, versionStr)
+
+	dynamicSidebarStyle := sidebarStyle.Copy().Width(l.sidebarWidth - 2).Height(l.sidebarHeight)
+	dynamicContentStyle := contentStyle.Copy().Width(l.contentWidth - 2).Height(l.contentHeight)
+
+	sidebarText := m.sidebarText(l)
+	contentText := m.detailsView(l)
+	var main string
+	switch {
+	case m.sidebarHidden:
+		main = dynamicContentStyle.Render(contentText)
+	case l.singleColumn && l.contentHeight == 0:
+		main = dynamicSidebarStyle.Render(sidebarText)
+	case l.singleColumn:
+		main = lipgloss.JoinVertical(
+			lipgloss.Left,
+			dynamicSidebarStyle.Render(sidebarText),
+			dynamicContentStyle.Render(contentText),
+		)
+	default:
+		main = lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			dynamicSidebarStyle.Render(sidebarText),
+			dynamicContentStyle.Render(contentText),
+		)
 	}
 
-	main := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		dynamicSidebarStyle.Render(sidebar.String()),
-		dynamicContentStyle.Render(content.String()),
-	)
-
-	footer := footerStyle.Width(80).Render("↑↓ navigate • enter attach • a add • k kill • r refresh • t toggle autostart • ? about • q quit")
+	footer := footerStyle.Width(l.width - 2).Render(footerText(fitHints(m.keys.footerHints(), l.width-6)))
 
 	layout := lipgloss.JoinVertical(
 		lipgloss.Center,
@@ -1305,18 +2489,45 @@ func (m model) View() string {
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, layout)
 }
 
-type clearErrorMsg struct{}
-
-var p *tea.Program
+func (m *model) refresh() {
+	m.refreshPending = true
+}
 
 func main() {
-	setupPaths()
+	configDirFlag := flag.String("config-dir", "", "directory holding config.json and sessions.json (overrides SPV_CONFIG_DIR)")
+	noColorFlag := flag.Bool("no-color", false, "disable colors, same as setting NO_COLOR")
+	flag.Parse()
+	args := flag.Args()
+	setupColors(*noColorFlag)
+
+	if err := setupPaths(*configDirFlag); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := migrateStore(); err != nil {
+		fmt.Printf("Error migrating session store: %v\n", err)
+		os.Exit(1)
+	}
+
+	themeErrors := loadUserThemes()
 
-	if len(os.Args) == 3 && os.Args[1] == "theme" {
-		themeName := os.Args[2]
+	if len(args) == 2 && args[0] == "theme" && args[1] == "list" {
+		listThemes(os.Stdout, themeErrors)
+		os.Exit(0)
+	}
+
+	if len(args) == 2 && args[0] == "theme" {
+		themeName := args[1]
 		if _, ok := themes[themeName]; !ok {
+			for _, err := range themeErrors {
+				if err.name == themeName {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
 			fmt.Printf("Error: Theme '%s' not found.\n", themeName)
-			fmt.Println("Available themes: slate, pink, forest, mellow, arctic, solarized, dracula, gruvbox, nord")
+			fmt.Printf("Available themes: %s\n", strings.Join(themeNames(), ", "))
 			os.Exit(1)
 		}
 		if err := saveTheme(themeName); err != nil {
@@ -1327,27 +2538,79 @@ func main() {
 		os.Exit(0)
 	}
 
+	if len(args) >= 1 && args[0] == "logs" {
+		logsFlags := flag.NewFlagSet("logs", flag.ExitOnError)
+		follow := logsFlags.Bool("f", false, "follow the log as it grows")
+		logsFlags.Parse(args[1:])
+		if logsFlags.NArg() != 1 {
+			fmt.Println("Usage: spv logs [-f] <session>")
+			os.Exit(1)
+		}
+		if err := followLog(logsFlags.Arg(0), *follow, os.Stdout); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(args) >= 1 {
+		clientName = "cli"
+		run, ok := commands[args[0]]
+		if !ok {
+			fmt.Printf("Error: unknown command '%s'.\n", args[0])
+			os.Exit(1)
+		}
+		err := run(args[1:])
+		pendingHooks.Wait()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	applyTheme(loadTheme())
 
-	sessions := getScreens()
-	cpuUsage, memUsage := getSystemStats()
+	var startupError string
+	if err := checkStore(); err != nil {
+		startupError = err.Error()
+	}
+
+	backend := connectBackend()
 
 	ti := textinput.New()
 	ti.CharLimit = 150
 	ti.Width = 35
 
 	m := model{
-		sessions:  sessions,
-		selected:  0,
-		textInput: ti,
-		state:     listView,
-		cpuUsage:  cpuUsage,
-		memUsage:  memUsage,
+		selected:    0,
+		textInput:   ti,
+		state:       listView,
+		backend:     backend,
+		exits:       newExitDetector(clientName),
+		watcher:     newLogWatcher(),
+		alerts:      make(map[string]sessionAlert),
+		showForeign: loadConfig().ShowForeign,
+		refreshing:  true,
+	}
+	if startupError != "" {
+		m.notify(noticeError, startupError)
+	}
+	for _, err := range themeErrors {
+		m.notify(noticeWarn, err.Error())
+	}
+	var keyWarnings []string
+	m.keys, keyWarnings = loadKeyMap(loadConfig())
+	for _, warning := range keyWarnings {
+		m.notify(noticeWarn, warning)
 	}
+	m.changes, _ = watchSessions()
+	m.pollEvery = m.pollInterval()
 
-	p = tea.NewProgram(m, tea.WithAltScreen())
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v", err)
 		os.Exit(1)
 	}
+	pendingHooks.Wait()
 }
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStripCronEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		crontab string
+		want    string
+	}{
+		{"empty", "", ""},
+		{"only spv", "@reboot /home/u/.config/spv/autostart.sh # spv-autostart\n", ""},
+		{
+			"keeps other jobs",
+			"0 * * * * backup\n@reboot /x/autostart.sh # spv-autostart\n*/5 * * * * sync\n",
+			"0 * * * * backup\n*/5 * * * * sync",
+		},
+		{"marker with trailing space", "@reboot /x # spv-autostart  \nMAILTO=me\n", "MAILTO=me"},
+		{"marker not at end", "# spv-autostart was here\n", "# spv-autostart was here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripCronEntry(tt.crontab); got != tt.want {
+				t.Errorf("stripCronEntry() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChooseAutostartBackendOverride(t *testing.T) {
+	tests := []struct {
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{"systemd", "systemd", false},
+		{"runit", "runit", false},
+		{"cron", "cron", false},
+		{"launchd", "", true},
+		{"Systemd", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.override, func(t *testing.T) {
+			got, reason, err := chooseAutostartBackend("openrc", tt.override)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("chooseAutostartBackend() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "valid values are auto, systemd") {
+				t.Errorf("error %q does not list the valid values", err)
+			}
+			if got != tt.want {
+				t.Errorf("chooseAutostartBackend() = %q, want %q", got, tt.want)
+			}
+			if err == nil && !strings.Contains(reason, "autostart_backend") {
+				t.Errorf("reason %q does not name the config setting", reason)
+			}
+		})
+	}
+}
+
+func TestOrderAutostartSessions(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []SessionEntry
+		want     []string
+		wantErr  string
+	}{
+		{
+			name:     "no dependencies keeps order",
+			sessions: []SessionEntry{{Name: "a"}, {Name: "b"}},
+			want:     []string{"a", "b"},
+		},
+		{
+			name: "dependencies start first",
+			sessions: []SessionEntry{
+				{Name: "web", DependsOn: []string{"api"}},
+				{Name: "api", DependsOn: []string{"db"}},
+				{Name: "db"},
+			},
+			want: []string{"db", "api", "web"},
+		},
+		{
+			name: "shared dependency once",
+			sessions: []SessionEntry{
+				{Name: "a", DependsOn: []string{"db"}},
+				{Name: "b", DependsOn: []string{"db"}},
+				{Name: "db"},
+			},
+			want: []string{"db", "a", "b"},
+		},
+		{
+			name:     "missing dependency",
+			sessions: []SessionEntry{{Name: "web", DependsOn: []string{"db"}}},
+			wantErr:  "not set to autostart",
+		},
+		{
+			name: "cycle",
+			sessions: []SessionEntry{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+			},
+			wantErr: "dependency cycle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := orderAutostartSessions(tt.sessions)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("orderAutostartSessions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("orderAutostartSessions() error = %v", err)
+			}
+			var got []string
+			for _, session := range ordered {
+				got = append(got, session.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("orderAutostartSessions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadyCheckCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   ReadyCheck
+		want    string
+		wantErr bool
+	}{
+		{"tcp port only", ReadyCheck{Type: "tcp", Target: "5432"}, "check_tcp '127.0.0.1' '5432'", false},
+		{"tcp host and port", ReadyCheck{Type: "tcp", Target: "db.local:5432"}, "check_tcp 'db.local' '5432'", false},
+		{"tcp empty host", ReadyCheck{Type: "tcp", Target: ":8080"}, "check_tcp '127.0.0.1' '8080'", false},
+		{"tcp ipv6", ReadyCheck{Type: "tcp", Target: "[::1]:6379"}, "check_tcp '::1' '6379'", false},
+		{"file", ReadyCheck{Type: "file", Target: "/run/app.sock"}, "test -e '/run/app.sock'", false},
+		{"log", ReadyCheck{Type: "log", Target: "/var/log/app.log", Pattern: "ready"}, "grep -qE 'ready' '/var/log/app.log'", false},
+		{"command quoting", ReadyCheck{Type: "command", Target: "curl -s 'http://x'"}, `bash -c 'curl -s '\''http://x'\'''`, false},
+		{"unknown", ReadyCheck{Type: "http"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readyCheckCommand(&tt.check)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readyCheckCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readyCheckCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAutostartStatus(t *testing.T) {
+	data := "1700000000\tdb\tready\t\n" +
+		"1700000001\tapi\ttimeout\tno answer within 30s\n" +
+		"1700000002\tweb\tskipped\twaiting on api\tdb\n" +
+		"garbage\n" +
+		"1700000003\t\tready\n" +
+		"1700000004\tdb\tstarted\n"
+	want := map[string]bootReport{
+		"db":  {status: "started"},
+		"api": {status: "timeout", detail: "no answer within 30s"},
+		"web": {status: "skipped", detail: "waiting on api\tdb"},
+	}
+	got := parseAutostartStatus(data)
+	if len(got) != len(want) {
+		t.Fatalf("parseAutostartStatus() = %v, want %v", got, want)
+	}
+	for name, report := range want {
+		if got[name] != report {
+			t.Errorf("parseAutostartStatus()[%s] = %+v, want %+v", name, got[name], report)
+		}
+	}
+}
+
+func TestParseScreenList(t *testing.T) {
+	output := "There are screens on:\n" +
+		"\t1234.spv_build\t(10/18/2026 09:00:00 AM)\t(Detached)\n" +
+		"\t5678.spv_web.v2\t(Attached)\n" +
+		"\t910.other\t(Detached)\n" +
+		"\t42.spv_dead\t(Dead ???)\n" +
+		"4 Sockets in /run/screen/S-user.\n"
+	tests := []struct {
+		name   string
+		output string
+		err    error
+		want   []screenSession
+	}{
+		{
+			name:   "sessions",
+			output: output,
+			want: []screenSession{
+				{id: "1234", name: "build", status: "detached", command: "shell", description: "A standard interactive shell session."},
+				{id: "5678", name: "web.v2", status: "attached", command: "shell", description: "A standard interactive shell session."},
+				{id: "910", name: "other", status: "detached", description: "Started outside spv.", foreign: true},
+			},
+		},
+		{
+			name:   "screen exits 1 when listing sockets",
+			output: output,
+			err:    errors.New("exit status 1"),
+			want: []screenSession{
+				{id: "1234", name: "build", status: "detached", command: "shell", description: "A standard interactive shell session."},
+				{id: "5678", name: "web.v2", status: "attached", command: "shell", description: "A standard interactive shell session."},
+				{id: "910", name: "other", status: "detached", description: "Started outside spv.", foreign: true},
+			},
+		},
+		{
+			name:   "no sockets",
+			output: "No Sockets found in /run/screen/S-user.\n",
+			err:    errors.New("exit status 1"),
+		},
+		{
+			name:   "screen missing",
+			output: "sh: screen: not found\n",
+			err:    errors.New("exit status 127"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseScreenList([]byte(tt.output), tt.err)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseScreenList() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutostartScriptQuoting(t *testing.T) {
+	tests := []struct {
+		name    string
+		cwd     string
+		command string
+	}{
+		{"plain", "/srv/app", "npm start"},
+		{"spaces in cwd", "/srv/my app", "npm start"},
+		{"double quotes", "/srv/app", `echo "hi there"`},
+		{"single quotes and variables", "/srv/it's $HOME", `echo '$HOME' "$PATH" $(id -u)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setupPaths(t.TempDir()); err != nil {
+				t.Fatal(err)
+			}
+			entry := SessionEntry{Name: "web", Command: tt.command, Cwd: tt.cwd}
+			script, err := generateAutostartScriptContent([]SessionEntry{entry})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var line string
+			for _, l := range strings.Split(script, "\n") {
+				if strings.Contains(l, "-dmS spv_web") {
+					line = l
+				}
+			}
+			// Stand-ins print the arguments bash would hand to cd and screen.
+			stubs := `cd() { printf '%s\n' "$1"; }; screen() { printf '%s' "${@: -1}"; }; `
+			out, err := exec.Command("bash", "-c", stubs+line).Output()
+			if err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+			want := tt.cwd + "\n" + tt.command + "; echo $? > " + shellQuote(entry.exitFile()) + "; exec bash"
+			if string(out) != want {
+				t.Errorf("got %q, want %q", out, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+type processStats struct {
+	cpuSeconds float64
+	rssBytes   uint64
+	started    time.Time
+}
+
+func collectProcessTree(p *process.Process, stats *processStats) {
+	if times, err := p.Times(); err == nil {
+		stats.cpuSeconds += times.User + times.System
+	}
+	if memInfo, err := p.MemoryInfo(); err == nil {
+		stats.rssBytes += memInfo.RSS
+	}
+	children, _ := p.Children()
+	for _, child := range children {
+		collectProcessTree(child, stats)
+	}
+}
+
+func sessionProcessStats(id string) (processStats, error) {
+	var stats processStats
+	pid, err := strconv.ParseInt(id, 10, 32)
+	if err != nil {
+		return stats, fmt.Errorf("invalid session pid %q", id)
+	}
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return stats, err
+	}
+	if created, err := p.CreateTime(); err == nil {
+		stats.started = time.UnixMilli(created)
+	}
+	collectProcessTree(p, &stats)
+	return stats, nil
+}
+
+func escapeLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
+
+func boolMetric(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type metricFamily struct {
+	name, help, kind string
+	samples          []string
+}
+
+func (f *metricFamily) add(session string, value interface{}) {
+	f.samples = append(f.samples, fmt.Sprintf("%s{session=\"%s\"} %v", f.name, escapeLabel(session), value))
+}
+
+func writeMetrics(w io.Writer, snap snapshot, entries []SessionEntry) {
+	running := make(map[string]SessionInfo)
+	for _, s := range snap.Sessions {
+		if s.Status != "exited" && !s.Foreign {
+			running[s.Name] = s
+		}
+	}
+	known := make(map[string]SessionEntry)
+	var names []string
+	for _, entry := range entries {
+		known[entry.Name] = entry
+		names = append(names, entry.Name)
+	}
+	for name := range running {
+		if _, ok := known[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	up := metricFamily{name: "spv_session_up", help: "Whether the screen session is running.", kind: "gauge"}
+	autostart := metricFamily{name: "spv_session_autostart", help: "Whether the session is started on boot.", kind: "gauge"}
+	restarts := metricFamily{name: "spv_session_restarts_total", help: "Number of times spv restarted the session.", kind: "counter"}
+	uptime := metricFamily{name: "spv_session_uptime_seconds", help: "Seconds since the screen process started.", kind: "gauge"}
+	cpuSeconds := metricFamily{name: "spv_session_cpu_seconds", help: "CPU time used by the processes currently in the session's tree; drops when children exit.", kind: "gauge"}
+	memory := metricFamily{name: "spv_session_memory_bytes", help: "Resident memory of the session's process tree.", kind: "gauge"}
+
+	for _, name := range names {
+		entry := known[name]
+		session, isRunning := running[name]
+		up.add(name, boolMetric(isRunning))
+		autostart.add(name, boolMetric(entry.Autostart || session.Autostart))
+		restarts.add(name, entry.Restarts)
+		if !isRunning {
+			continue
+		}
+		stats, err := sessionProcessStats(session.ID)
+		if err != nil {
+			continue
+		}
+		if !stats.started.IsZero() {
+			uptime.add(name, int64(time.Since(stats.started).Seconds()))
+		}
+		cpuSeconds.add(name, stats.cpuSeconds)
+		memory.add(name, stats.rssBytes)
+	}
+
+	for _, f := range []metricFamily{up, autostart, restarts, uptime, cpuSeconds, memory} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, sample := range f.samples {
+			fmt.Fprintln(w, sample)
+		}
+	}
+	fmt.Fprintf(w, "# HELP spv_host_cpu_percent Host CPU usage.\n# TYPE spv_host_cpu_percent gauge\nspv_host_cpu_percent %v\n", snap.CPU)
+	fmt.Fprintf(w, "# HELP spv_host_memory_percent Host memory usage.\n# TYPE spv_host_memory_percent gauge\nspv_host_memory_percent %v\n", snap.Mem)
+}
+
+func (d *daemon) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	d.mu.RLock()
+	snap := d.current
+	d.mu.RUnlock()
+	store, _ := loadStore()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeMetrics(w, snap, store.Sessions)
+}
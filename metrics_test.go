@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []SessionInfo
+		entries  []SessionEntry
+		want     []string
+		wantNot  []string
+	}{
+		{
+			name:    "stopped autostart session",
+			entries: []SessionEntry{{Name: "db", Autostart: true, Restarts: 2}},
+			want: []string{
+				`spv_session_up{session="db"} 0`,
+				`spv_session_autostart{session="db"} 1`,
+				`spv_session_restarts_total{session="db"} 2`,
+			},
+			wantNot: []string{`spv_session_uptime_seconds{session="db"}`},
+		},
+		{
+			name:     "running session without an entry",
+			sessions: []SessionInfo{{Name: "adhoc", Status: "detached", ID: "not-a-pid"}},
+			want: []string{
+				`spv_session_up{session="adhoc"} 1`,
+				`spv_session_autostart{session="adhoc"} 0`,
+				`spv_session_restarts_total{session="adhoc"} 0`,
+			},
+		},
+		{
+			name: "foreign and exited sessions are not up",
+			sessions: []SessionInfo{
+				{Name: "other", Status: "detached", Foreign: true},
+				{Name: "web", Status: "exited"},
+			},
+			entries: []SessionEntry{{Name: "web"}},
+			want:    []string{`spv_session_up{session="web"} 0`},
+			wantNot: []string{`session="other"`},
+		},
+		{
+			name:    "label escaping",
+			entries: []SessionEntry{{Name: `a"b\c`}},
+			want:    []string{`spv_session_up{session="a\"b\\c"} 0`},
+		},
+		{
+			name: "types",
+			want: []string{
+				"# TYPE spv_session_restarts_total counter",
+				"# TYPE spv_session_cpu_seconds gauge",
+				"# TYPE spv_host_cpu_percent gauge",
+			},
+			wantNot: []string{"spv_session_cpu_seconds_total"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			writeMetrics(&out, snapshot{Sessions: tt.sessions}, tt.entries)
+			lines := strings.Split(out.String(), "\n")
+			for _, want := range tt.want {
+				found := false
+				for _, line := range lines {
+					found = found || line == want
+				}
+				if !found {
+					t.Errorf("missing line %q in\n%s", want, out.String())
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(out.String(), unwanted) {
+					t.Errorf("unexpected %q in\n%s", unwanted, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	doubleClickInterval = 400 * time.Millisecond
+	wheelLines          = 3
+)
+
+func (m model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch m.state {
+	case viewingLogs:
+		m.logViewport, cmd = m.logViewport.Update(msg)
+		return m, cmd
+	case showingHistory:
+		m.historyViewport, cmd = m.historyViewport.Update(msg)
+		return m, cmd
+	case showingNotices:
+		m.noticeViewport, cmd = m.noticeViewport.Update(msg)
+		return m, cmd
+	case listView:
+	default:
+		return m, nil
+	}
+
+	if msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+	l := m.layout()
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		if m.overDetails(l, msg.X, msg.Y) {
+			m.scrollDetails(l, -wheelLines)
+			return m, nil
+		}
+		return m.runAction("up")
+	case tea.MouseButtonWheelDown:
+		if m.overDetails(l, msg.X, msg.Y) {
+			m.scrollDetails(l, wheelLines)
+			return m, nil
+		}
+		return m.runAction("down")
+	case tea.MouseButtonLeft:
+		if action := m.footerActionAt(l, msg.X, msg.Y); action != "" {
+			return m.runAction(action)
+		}
+		index := m.sessionAt(l, msg.X, msg.Y)
+		if index < 0 {
+			return m, nil
+		}
+		doubleClick := index == m.lastClickIndex && time.Since(m.lastClick) < doubleClickInterval
+		m.selected = index
+		m.lastClick, m.lastClickIndex = time.Now(), index
+		if doubleClick {
+			m.lastClick = time.Time{}
+			return m.runAction("attach")
+		}
+	}
+	return m, nil
+}
+
+func (m model) headerHeight(l layout) int {
+	return lipgloss.Height(m.headerView(l, Version))
+}
+
+func (m model) overDetails(l layout, x, y int) bool {
+	switch {
+	case m.sidebarHidden:
+		return true
+	case l.singleColumn:
+		return y >= m.headerHeight(l)+l.sidebarHeight+2
+	}
+	return x >= l.sidebarWidth
+}
+
+// detailsKey identifies the session the details pane shows, so its scroll
+// position resets when the selection moves.
+func (m model) detailsKey() string {
+	if m.selected >= len(m.sessions) {
+		return ""
+	}
+	return m.sessions[m.selected].host + "/" + m.sessions[m.selected].name
+}
+
+func (m model) detailsOffset() int {
+	if m.detailsFor != m.detailsKey() {
+		return 0
+	}
+	return m.detailsScroll
+}
+
+func (m *model) scrollDetails(l layout, delta int) {
+	overflow := lipgloss.Height(m.detailsText(l)) - (l.contentHeight - 2)
+	m.detailsScroll = min(max(m.detailsOffset()+delta, 0), max(overflow, 0))
+	m.detailsFor = m.detailsKey()
+}
+
+func (m model) sessionAt(l layout, x, y int) int {
+	if m.sidebarHidden || x >= l.sidebarWidth {
+		return -1
+	}
+	rows, start, end := m.sidebarWindow(l)
+	// The list starts below the header, the sidebar's border and padding,
+	// and the title with the blank line after it.
+	firstRow := m.headerHeight(l) + 2 + lipgloss.Height(m.sidebarTitle(l)) + 1
+	if start > 0 {
+		firstRow++
+	}
+	row := start + y - firstRow
+	if y < firstRow || row >= end {
+		return -1
+	}
+	return rows[row].session
+}
+
+func (m model) footerActionAt(l layout, x, y int) string {
+	if y != m.height-2 {
+		return ""
+	}
+	hints := fitHints(m.keys.footerHints(), l.width-6)
+	offset := 3 + (l.width-6-lipgloss.Width(footerText(hints)))/2
+	for i, hint := range hints {
+		if i > 0 {
+			offset += lipgloss.Width(hintSeparator)
+		}
+		width := lipgloss.Width(hint.String())
+		if x >= offset && x < offset+width {
+			return hint.action
+		}
+		offset += width
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestFooterActionAt(t *testing.T) {
+	keys, _ := loadKeyMap(Config{})
+	tests := []struct {
+		name  string
+		width int
+		hint  string
+		dx    int
+		dy    int
+		want  string
+	}{
+		{"first hint", 100, "↑ move up", 0, 0, "up"},
+		{"last column of a hint", 100, "k kill", 5, 0, "kill"},
+		{"past the end of a hint", 100, "k kill", 6, 0, ""},
+		{"separator", 100, "k kill", -2, 0, ""},
+		{"row above the footer", 100, "k kill", 0, -1, ""},
+		{"narrow footer", 60, "a add", 1, 0, "add"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{width: tt.width, height: 40, keys: keys}
+			l := m.layout()
+			text := footerText(fitHints(keys.footerHints(), l.width-6))
+			i := strings.Index(text, tt.hint)
+			if i < 0 {
+				t.Fatalf("%q not in footer %q", tt.hint, text)
+			}
+			x := 3 + (l.width-6-lipgloss.Width(text))/2 + lipgloss.Width(text[:i]) + tt.dx
+			if got := m.footerActionAt(l, x, m.height-2+tt.dy); got != tt.want {
+				t.Errorf("footerActionAt(%d) = %q, want %q", x, got, tt.want)
+			}
+		})
+	}
+}
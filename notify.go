@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const noticeLogSize = 200
+
+type noticeLevel int
+
+const (
+	noticeInfo noticeLevel = iota
+	noticeWarn
+	noticeError
+)
+
+func (l noticeLevel) String() string {
+	switch l {
+	case noticeWarn:
+		return "warn"
+	case noticeError:
+		return "error"
+	}
+	return "info"
+}
+
+func (l noticeLevel) duration() time.Duration {
+	switch l {
+	case noticeWarn:
+		return 5 * time.Second
+	case noticeError:
+		return 8 * time.Second
+	}
+	return 3 * time.Second
+}
+
+type notice struct {
+	id        int
+	level     noticeLevel
+	text      string
+	at        time.Time
+	scheduled bool
+}
+
+type expireNoticeMsg struct {
+	id int
+}
+
+func alertLevel(severity string) noticeLevel {
+	switch severity {
+	case "error":
+		return noticeError
+	case "info":
+		return noticeInfo
+	}
+	return noticeWarn
+}
+
+func (m *model) notify(level noticeLevel, text string) {
+	m.nextNotice++
+	n := notice{id: m.nextNotice, level: level, text: text, at: time.Now()}
+	m.notices = append(m.notices, n)
+	m.noticeLog = append(m.noticeLog, n)
+	if len(m.noticeLog) > noticeLogSize {
+		m.noticeLog = m.noticeLog[len(m.noticeLog)-noticeLogSize:]
+	}
+}
+
+func (m *model) scheduleNotice() tea.Cmd {
+	if len(m.notices) == 0 || m.notices[0].scheduled {
+		return nil
+	}
+	m.notices[0].scheduled = true
+	id := m.notices[0].id
+	return tea.Tick(m.notices[0].level.duration(), func(time.Time) tea.Msg {
+		return expireNoticeMsg{id: id}
+	})
+}
+
+func (m *model) expireNotice(id int) {
+	if len(m.notices) > 0 && m.notices[0].id == id {
+		m.notices = m.notices[1:]
+	}
+}
+
+func (m *model) dismissNotice() {
+	if len(m.notices) > 0 {
+		m.notices = m.notices[1:]
+	}
+}
+
+func noticeStyle(level noticeLevel) lipgloss.Style {
+	switch level {
+	case noticeError:
+		return errorTextStyle
+	case noticeWarn:
+		return statusDetachedStyle
+	}
+	return accentStyle
+}
+
+func (m model) noticeView() string {
+	if len(m.notices) == 0 {
+		return ""
+	}
+	current := m.notices[0]
+	view := noticeStyle(current.level).Render(current.text)
+	if len(m.notices) > 1 {
+		view += "\n" + mutedTextStyle.Render(fmt.Sprintf("+%d more • %s dismiss", len(m.notices)-1, m.keys.Dismiss.Help().Key))
+	}
+	return view
+}
+
+func (m *model) refreshNoticeView() {
+	width := m.width - 6
+	height := m.height - 8
+	if width < 10 {
+		width = 10
+	}
+	if height < 3 {
+		height = 3
+	}
+	m.noticeViewport.Width = width
+	m.noticeViewport.Height = height
+
+	var lines []string
+	if len(m.noticeLog) == 0 {
+		lines = append(lines, mutedTextStyle.Render("No notifications yet."))
+	}
+	for _, n := range m.noticeLog {
+		prefix := fmt.Sprintf("%s  %-5s  ", n.at.Format("15:04:05"), n.level)
+		lines = append(lines, mutedTextStyle.Render(prefix)+noticeStyle(n.level).Render(n.text))
+	}
+	m.noticeViewport.SetContent(lipgloss.NewStyle().Width(width).Render(strings.Join(lines, "\n")))
+}
+
+func (m model) noticeLogView() string {
+	box := contentStyle.Copy().Width(m.width - 2).Render(
+		accentStyle.Render("notifications") + "\n\n" +
+			m.noticeViewport.View() + "\n\n" +
+			mutedTextStyle.Render(m.keys.scrollHints()),
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const paletteRows = 10
+
+type paletteCommand struct {
+	name    string
+	title   string
+	key     string
+	prompt  string
+	initial func(m model) string
+	choices func(m model) []string
+	run     func(m model, arg string) (tea.Model, tea.Cmd)
+}
+
+func (m model) paletteCommands() []paletteCommand {
+	var commands []paletteCommand
+	for _, action := range m.keys.actions() {
+		name := action.name
+		if name == "palette" || name == "rename" {
+			continue
+		}
+		commands = append(commands, paletteCommand{
+			name:  name,
+			title: action.title,
+			key:   action.binding.Help().Key,
+			run: func(m model, _ string) (tea.Model, tea.Cmd) {
+				return m.runAction(name)
+			},
+		})
+	}
+
+	commands = append(commands,
+		paletteCommand{
+			name:   "rename",
+			title:  "rename session",
+			key:    m.keys.Rename.Help().Key,
+			prompt: "New name",
+			initial: func(m model) string {
+				return m.paletteTarget.name
+			},
+			run: func(m model, arg string) (tea.Model, tea.Cmd) {
+				name := m.paletteTarget.name
+				arg = strings.TrimSpace(arg)
+				if arg == "" || arg == name {
+					return m, nil
+				}
+				backend := m.backendFor(m.paletteTarget)
+				return m, actionCmd(func() error {
+					return backend.rename(name, arg)
+				}, fmt.Sprintf("Renamed %s to %s", name, arg))
+			},
+		},
+		paletteCommand{
+			name:   "sort_by",
+			title:  "sort sessions by",
+			prompt: "Sort by",
+			choices: func(model) []string {
+				var modes []string
+				for mode := sortNone; mode < sortModeCount; mode++ {
+					modes = append(modes, mode.String())
+				}
+				return modes
+			},
+			run: func(m model, arg string) (tea.Model, tea.Cmd) {
+				for mode := sortNone; mode < sortModeCount; mode++ {
+					if mode.String() == arg {
+						m.sortMode = mode
+					}
+				}
+				m.rearrange()
+				return m, nil
+			},
+		},
+		paletteCommand{
+			name:   "switch_host",
+			title:  "switch host",
+			prompt: "Host",
+			choices: func(model) []string {
+				return hostChoices()
+			},
+			run: func(m model, arg string) (tea.Model, tea.Cmd) {
+				return m, m.selectHost(arg)
+			},
+		},
+	)
+	return commands
+}
+
+// fuzzyScore matches query as a subsequence of text, rewarding runs of
+// consecutive characters and matches at the start of words.
+func fuzzyScore(query, text string) (int, bool) {
+	query = strings.ToLower(query)
+	text = strings.ToLower(text)
+	score, last := 0, -1
+	for _, r := range query {
+		i := strings.IndexRune(text[last+1:], r)
+		if i < 0 {
+			return 0, false
+		}
+		pos := last + 1 + i
+		switch {
+		case pos == last+1:
+			score += 3
+		case pos == 0 || strings.ContainsRune(" _-/", rune(text[pos-1])):
+			score += 2
+		default:
+			score++
+		}
+		last = pos
+	}
+	return score*10 - len(text), true
+}
+
+func (m model) paletteMatches() []paletteCommand {
+	query := strings.TrimSpace(m.textInput.Value())
+	type match struct {
+		command paletteCommand
+		score   int
+	}
+	var matches []match
+	for _, command := range m.paletteCommands() {
+		score, ok := fuzzyScore(query, command.title)
+		if nameScore, nameOk := fuzzyScore(query, command.name); nameOk && (!ok || nameScore > score) {
+			score, ok = nameScore, true
+		}
+		if ok {
+			matches = append(matches, match{command, score})
+		}
+	}
+	if query != "" {
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	}
+	commands := make([]paletteCommand, len(matches))
+	for i, match := range matches {
+		commands[i] = match.command
+	}
+	return commands
+}
+
+func (m model) paletteChoices() []string {
+	command, ok := m.paletteArgCommand()
+	if !ok || command.choices == nil {
+		return nil
+	}
+	query := strings.TrimSpace(m.textInput.Value())
+	var choices []string
+	for _, choice := range command.choices(m) {
+		if _, ok := fuzzyScore(query, choice); ok {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+func (m model) paletteArgCommand() (paletteCommand, bool) {
+	for _, command := range m.paletteCommands() {
+		if command.name == m.paletteCommand {
+			return command, true
+		}
+	}
+	return paletteCommand{}, false
+}
+
+func (m model) openPalette() (tea.Model, tea.Cmd) {
+	m.state = showingPalette
+	m.paletteCommand = ""
+	m.paletteCursor = 0
+	m.textInput.Placeholder = "Type a command"
+	m.textInput.SetValue("")
+	m.textInput.Focus()
+	return m, textinput.Blink
+}
+
+func (m model) promptPalette(command paletteCommand) (tea.Model, tea.Cmd) {
+	if len(m.sessions) > 0 && m.selected < len(m.sessions) {
+		m.paletteTarget = m.sessions[m.selected]
+	}
+	if command.name == "rename" {
+		if m.paletteTarget.name == "" {
+			return m.closePalette(), nil
+		}
+		if m.paletteTarget.host != "" || m.paletteTarget.foreign {
+			m.notify(noticeWarn, "Renaming is only available for local sessions started by spv")
+			return m.closePalette(), nil
+		}
+	}
+	m.state = showingPalette
+	m.paletteCommand = command.name
+	m.paletteCursor = 0
+	m.textInput.Placeholder = command.prompt
+	m.textInput.SetValue("")
+	if command.initial != nil {
+		m.textInput.SetValue(command.initial(m))
+	}
+	m.textInput.Focus()
+	return m, textinput.Blink
+}
+
+func (m model) closePalette() model {
+	m.state = listView
+	m.paletteCommand = ""
+	m.textInput.Blur()
+	m.textInput.SetValue("")
+	return m
+}
+
+func (m model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	var count int
+	if m.paletteCommand == "" {
+		count = len(m.paletteMatches())
+	} else {
+		count = len(m.paletteChoices())
+	}
+
+	switch msg.String() {
+	case "esc":
+		return m.closePalette(), nil, true
+
+	case "up", "ctrl+p":
+		if m.paletteCursor > 0 {
+			m.paletteCursor--
+		}
+		return m, nil, true
+
+	case "down", "ctrl+n":
+		if m.paletteCursor < count-1 {
+			m.paletteCursor++
+		}
+		return m, nil, true
+
+	case "enter":
+		if m.paletteCommand == "" {
+			matches := m.paletteMatches()
+			if m.paletteCursor >= len(matches) {
+				return m, nil, true
+			}
+			command := matches[m.paletteCursor]
+			if command.prompt != "" {
+				model, cmd := m.promptPalette(command)
+				return model, cmd, true
+			}
+			model, cmd := command.run(m.closePalette(), "")
+			return model, cmd, true
+		}
+
+		command, ok := m.paletteArgCommand()
+		if !ok {
+			return m.closePalette(), nil, true
+		}
+		arg := m.textInput.Value()
+		if command.choices != nil {
+			choices := m.paletteChoices()
+			if m.paletteCursor >= len(choices) {
+				return m, nil, true
+			}
+			arg = choices[m.paletteCursor]
+		}
+		model, cmd := command.run(m.closePalette(), arg)
+		return model, cmd, true
+	}
+
+	m.paletteCursor = 0
+	return m, nil, false
+}
+
+func (m model) paletteView() string {
+	width := 56
+	if m.width-8 < width {
+		width = m.width - 8
+	}
+
+	title := "commands"
+	freeText := false
+	var rows [][2]string
+	if m.paletteCommand == "" {
+		for _, command := range m.paletteMatches() {
+			rows = append(rows, [2]string{command.title, command.key})
+		}
+	} else if command, ok := m.paletteArgCommand(); ok {
+		title = command.title
+		if command.name == "rename" {
+			title += " " + m.paletteTarget.name
+		}
+		freeText = command.choices == nil
+		for _, choice := range m.paletteChoices() {
+			rows = append(rows, [2]string{choice, ""})
+		}
+	}
+
+	start := 0
+	if m.paletteCursor >= paletteRows {
+		start = m.paletteCursor - paletteRows + 1
+	}
+	var list strings.Builder
+	for i := start; i < len(rows) && i < start+paletteRows; i++ {
+		label, hint := rows[i][0], rows[i][1]
+		gap := width - lipgloss.Width(label) - lipgloss.Width(hint)
+		if gap < 1 {
+			gap = 1
+		}
+		if i == m.paletteCursor {
+			list.WriteString(selectedStyle.Render(label) + strings.Repeat(" ", gap) + mutedTextStyle.Render(hint) + "\n")
+		} else {
+			list.WriteString(label + strings.Repeat(" ", gap) + mutedTextStyle.Render(hint) + "\n")
+		}
+	}
+	if len(rows) == 0 && !freeText {
+		list.WriteString(mutedTextStyle.Render("no matches") + "\n")
+	}
+
+	body := accentStyle.Render(title) + "\n\n" + m.textInput.View() + "\n\n"
+	if freeText {
+		body += mutedTextStyle.Render("enter confirm • esc cancel")
+	} else {
+		body += list.String() + "\n" + mutedTextStyle.Render("↑↓ choose • enter run • esc cancel")
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, inputStyle.Render(body))
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		text      string
+		wantScore int
+		wantOk    bool
+	}{
+		{"empty query", "", "kill", -4, true},
+		{"prefix run", "kil", "kill session", 78, true},
+		{"word start", "ks", "kill session", 38, true},
+		{"case insensitive", "KS", "Kill Session", 38, true},
+		{"scattered", "kl", "kill", 36, true},
+		{"shorter text wins a tie", "kl", "kill session", 28, true},
+		{"out of order", "lk", "kill", 0, false},
+		{"missing character", "xyz", "kill session", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := fuzzyScore(tt.query, tt.text)
+			if score != tt.wantScore || ok != tt.wantOk {
+				t.Errorf("fuzzyScore(%q, %q) = %d, %v, want %d, %v", tt.query, tt.text, score, ok, tt.wantScore, tt.wantOk)
+			}
+		})
+	}
+}
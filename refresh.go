@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	screenTimeout       = 3 * time.Second
+	staleAfter          = 3 * time.Second
+	readTimeout         = 3 * time.Second
+	defaultPollInterval = time.Second
+	watchedPollInterval = 10 * time.Second
+)
+
+// pollMsg carries the hostGen it was scheduled for, so switching hosts can
+// replace the poll loop with one at the new host's interval.
+type pollMsg struct {
+	gen int
+}
+
+type changeMsg struct {
+	closed bool
+}
+
+func screenDir() string {
+	if dir := os.Getenv("SCREENDIR"); dir != "" {
+		return dir
+	}
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	for _, base := range []string{"/run/screen", "/var/run/screen", "/tmp/screens"} {
+		dir := filepath.Join(base, "S-"+name)
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	return filepath.Join("/run/screen", "S-"+name)
+}
+
+func (c Config) pollInterval() time.Duration {
+	if interval, err := time.ParseDuration(c.PollInterval); err == nil && interval > 0 {
+		return interval
+	}
+	return defaultPollInterval
+}
+
+func watchSessions() (<-chan struct{}, time.Duration) {
+	changes, err := watchChanges(screenDir(), []string{sessionFile, autostartStatusFile})
+	if err != nil {
+		return nil, loadConfig().pollInterval()
+	}
+	return changes, watchedPollInterval
+}
+
+func pollCmd(interval time.Duration, gen int) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return pollMsg{gen: gen}
+	})
+}
+
+// pollInterval is how often the list is reloaded without a change event.
+// The watcher only sees local sessions, so remote hosts and the "all" view
+// keep the configured poll_interval.
+func (m model) pollInterval() time.Duration {
+	if m.changes != nil && m.host == "" {
+		return watchedPollInterval
+	}
+	return loadConfig().pollInterval()
+}
+
+func waitForChange(changes <-chan struct{}) tea.Cmd {
+	if changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		_, ok := <-changes
+		return changeMsg{closed: !ok}
+	}
+}
+
+type refreshMsg struct {
+	gen  int
+	snap snapshot
+	err  error
+}
+
+func refreshCmd(backend sessionBackend, local bool, gen int) tea.Cmd {
+	return func() tea.Msg {
+		if local {
+			rotateSessionLogs()
+		}
+		snap, err := backend.snapshot()
+		return refreshMsg{gen: gen, snap: snap, err: err}
+	}
+}
+
+// timeoutCmd runs fn in the background and reports timedOut instead when it
+// takes longer than readTimeout, so a hung disk can't stall the TUI.
+func timeoutCmd(fn func() tea.Msg, timedOut tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		done := make(chan tea.Msg, 1)
+		go func() { done <- fn() }()
+		select {
+		case msg := <-done:
+			return msg
+		case <-time.After(readTimeout):
+			return timedOut
+		}
+	}
+}
+
+type statsMsg struct {
+	cpu, mem float64
+	ok       bool
+}
+
+func statsCmd() tea.Cmd {
+	return timeoutCmd(func() tea.Msg {
+		cpu, mem := getSystemStats()
+		return statsMsg{cpu: cpu, mem: mem, ok: true}
+	}, statsMsg{})
+}
+
+// actionMsg reports the outcome of a session operation that ran in the
+// background, with the notice to show when it succeeded.
+type actionMsg struct {
+	err  error
+	done string
+}
+
+func actionCmd(fn func() error, done string) tea.Cmd {
+	return func() tea.Msg {
+		return actionMsg{err: fn(), done: done}
+	}
+}
+
+func (m model) createCmd(entry SessionEntry) tea.Cmd {
+	backend := m.backend
+	return actionCmd(func() error {
+		return backend.create(entry)
+	}, "")
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	next := updated.(model)
+	if next.refreshPending {
+		next.refreshPending = false
+		cmd = tea.Batch(cmd, next.startRefresh())
+	}
+	if expire := next.scheduleNotice(); expire != nil {
+		cmd = tea.Batch(cmd, expire)
+	}
+	return next, cmd
+}
+
+func (m *model) startRefresh() tea.Cmd {
+	if m.refreshing {
+		m.refreshQueued = true
+		return nil
+	}
+	m.refreshing = true
+	return refreshCmd(m.backend, m.runsLocally(), m.refreshGen)
+}
+
+func (m *model) applyRefresh(msg refreshMsg) {
+	m.refreshing = false
+	if m.refreshQueued || msg.gen != m.refreshGen {
+		m.refreshQueued = false
+		m.refresh()
+	}
+	if msg.gen != m.refreshGen {
+		return
+	}
+
+	snap, err := msg.snap, msg.err
+	switch backend := m.backend.(type) {
+	case remoteBackend:
+		m.hostStatus = ""
+		if err != nil {
+			m.hostStatus = err.Error()
+		}
+	case *daemonClient:
+		if err != nil {
+			m.notify(noticeWarn, fmt.Sprintf("spv daemon unreachable, switching to local mode: %v", err))
+			m.backend = localBackend{source: clientName}
+			m.refreshGen++
+			m.refresh()
+			return
+		}
+	case *aggregateBackend:
+		if _, ok := backend.local.(*daemonClient); ok && err != nil {
+			m.notify(noticeWarn, fmt.Sprintf("spv daemon unreachable, switching to local mode: %v", err))
+			backend.local = localBackend{source: clientName}
+			m.refreshGen++
+			m.refresh()
+			return
+		}
+	}
+	if _, ok := m.backend.(remoteBackend); !ok && err != nil {
+		if m.refreshErr == nil {
+			m.notify(noticeError, fmt.Sprintf("Refresh failed: %v", err))
+		}
+		m.refreshErr = err
+		return
+	}
+
+	m.refreshErr = nil
+	m.lastRefresh = time.Now()
+	m.cpuUsage, m.memUsage = snap.CPU, snap.Mem
+	m.hostStatuses = snap.Hosts
+	sessions := snap.screenSessions()
+	if m.runsLocally() {
+		var local []screenSession
+		for _, session := range sessions {
+			if session.host == "" {
+				local = append(local, session)
+			}
+		}
+		m.exits.observe(local)
+	} else {
+		m.exits.release()
+	}
+	m.sessions = m.arrange(sessions)
+	if m.selected >= len(m.sessions) && len(m.sessions) > 0 {
+		m.selected = len(m.sessions) - 1
+	} else if len(m.sessions) == 0 {
+		m.selected = 0
+	}
+}
+
+func (m model) staleness() string {
+	if m.refreshErr != nil {
+		return statusDetachedStyle.Render("stale") + " "
+	}
+	if !m.lastRefresh.IsZero() && time.Since(m.lastRefresh) > m.pollEvery+staleAfter {
+		return statusDetachedStyle.Render("stale "+formatDuration(time.Since(m.lastRefresh))) + " "
+	}
+	return ""
+}
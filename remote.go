@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// remoteTimeout bounds one-off ssh commands such as kill, send and preview,
+// so an unresponsive host can't hang the caller.
+const remoteTimeout = 15 * time.Second
+
+type HostProfile struct {
+	Name     string   `json:"name"`
+	Address  string   `json:"address"`
+	Port     int      `json:"port,omitempty"`
+	Identity string   `json:"identity,omitempty"`
+	Options  []string `json:"ssh_options,omitempty"`
+	Runner   []string `json:"runner,omitempty"`
+}
+
+func (h HostProfile) command(tty bool, remoteCommand string) *exec.Cmd {
+	return h.commandContext(context.Background(), tty, remoteCommand)
+}
+
+func (h HostProfile) commandContext(ctx context.Context, tty bool, remoteCommand string) *exec.Cmd {
+	if len(h.Runner) > 0 {
+		args := append(append([]string{}, h.Runner[1:]...), "sh", "-c", remoteCommand)
+		return exec.CommandContext(ctx, h.Runner[0], args...)
+	}
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=5"}
+	if tty {
+		args = []string{"-t", "-o", "ConnectTimeout=5"}
+	}
+	if h.Port != 0 {
+		args = append(args, "-p", strconv.Itoa(h.Port))
+	}
+	if h.Identity != "" {
+		args = append(args, "-i", h.Identity)
+	}
+	args = append(args, h.Options...)
+	args = append(args, h.Address, remoteCommand)
+	return exec.CommandContext(ctx, "ssh", args...)
+}
+
+func (h HostProfile) run(remoteCommand string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	return h.runContext(ctx, remoteCommand)
+}
+
+func (h HostProfile) runContext(ctx context.Context, remoteCommand string) ([]byte, error) {
+	cmd := h.commandContext(ctx, false, remoteCommand)
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, fmt.Errorf("%s timed out", h.Name)
+	}
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 255 {
+			return output, fmt.Errorf("%s unreachable: %s", h.Name, strings.TrimSpace(string(output)))
+		}
+	}
+	return output, err
+}
+
+func (h HostProfile) list(ctx context.Context) ([]SessionInfo, error) {
+	output, err := h.runContext(ctx, "screen -ls")
+	if err != nil && !strings.Contains(strings.ToLower(string(output)), "socket") {
+		return []SessionInfo{}, err
+	}
+	sessions := newSnapshot(parseScreenList(output, nil), 0, 0).Sessions
+	for i := range sessions {
+		sessions[i].Host = h.Name
+	}
+	return sessions, nil
+}
+
+func findHost(name string) (HostProfile, bool) {
+	for _, host := range loadConfig().Hosts {
+		if host.Name == name {
+			return host, true
+		}
+	}
+	return HostProfile{}, false
+}
+
+type remoteBackend struct {
+	host HostProfile
+}
+
+func (b remoteBackend) snapshot() (snapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hostTimeout)
+	defer cancel()
+	sessions, err := b.host.list(ctx)
+	return snapshot{Sessions: sessions}, err
+}
+
+func (b remoteBackend) create(entry SessionEntry) error {
+	inner := "exec bash"
+	if entry.Command != "shell" && entry.Command != "" {
+		inner = entry.Command + "; exec bash"
+	}
+	if entry.Cwd != "" {
+		inner = fmt.Sprintf("cd %s 2>/dev/null; %s", shellQuote(entry.Cwd), inner)
+	}
+	output, err := b.host.run(fmt.Sprintf("screen -dmS %s bash -c %s", shellQuote("spv_"+entry.Name), shellQuote(inner)))
+	if err != nil {
+		err = fmt.Errorf("failed to create session on %s: %v: %s", b.host.Name, err, strings.TrimSpace(string(output)))
+	}
+	recordEvent(clientName+"@"+b.host.Name, "create", entry.Name, err)
+	return err
+}
+
+func (b remoteBackend) kill(name string) error {
+	output, err := b.host.run(fmt.Sprintf("screen -S %s -X quit", shellQuote("spv_"+name)))
+	if err != nil {
+		err = fmt.Errorf("failed to kill session on %s: %v: %s", b.host.Name, err, strings.TrimSpace(string(output)))
+	}
+	recordEvent(clientName+"@"+b.host.Name, "kill", name, err)
+	return err
+}
+
+func (b remoteBackend) send(name, text string) error {
+	output, err := b.host.run(fmt.Sprintf("screen -S %s -X stuff %s", shellQuote("spv_"+name), shellQuote(text)))
+	if err != nil {
+		err = fmt.Errorf("failed to send to session on %s: %v: %s", b.host.Name, err, strings.TrimSpace(string(output)))
+	}
+	recordEvent(clientName+"@"+b.host.Name, "send", name, err)
+	return err
+}
+
+func (b remoteBackend) restart(name string) error {
+	return fmt.Errorf("restart is only available for local sessions")
+}
+
+func (b remoteBackend) setAutostart(name string, on bool) (string, error) {
+	return "", fmt.Errorf("autostart is only available for local sessions")
+}
+
+func (b remoteBackend) rename(name, newName string) error {
+	return fmt.Errorf("rename is only available for local sessions")
+}
+
+func (b remoteBackend) adopt(session screenSession, name, command string) error {
+	return fmt.Errorf("adopting is only available for local sessions")
+}
+
+func (b remoteBackend) attach(session screenSession) *exec.Cmd {
+	return b.host.command(true, fmt.Sprintf("screen -r %s", shellQuote(session.screenName())))
+}
+
+func (b remoteBackend) preview(name string) (string, error) {
+	output, err := b.host.run(fmt.Sprintf(
+		`f=$(mktemp) && screen -S %s -X hardcopy -h "$f" && sleep 0.2 && cat "$f"; rm -f "$f"`,
+		shellQuote("spv_"+name),
+	))
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return cleanLogOutput(output), nil
+}
+
+func hostChoices() []string {
+	choices := []string{"local"}
+	for _, host := range loadConfig().Hosts {
+		choices = append(choices, host.Name)
+	}
+	if len(choices) > 1 {
+		choices = append(choices, "all")
+	}
+	return choices
+}
+
+type hostMsg struct {
+	gen     int
+	host    string
+	backend sessionBackend
+}
+
+// selectHost switches to another host. Local and "all" need to probe the
+// daemon first, so the switch happens when the returned command reports.
+func (m *model) selectHost(name string) tea.Cmd {
+	m.hostGen++
+	gen := m.hostGen
+	if host, ok := findHost(name); ok && name != "local" && name != "all" {
+		return m.applyHost(hostMsg{gen: gen, host: name, backend: remoteBackend{host: host}})
+	}
+	return func() tea.Msg {
+		if name == "all" {
+			return hostMsg{gen: gen, host: name, backend: newAggregateBackend(connectBackend(), loadConfig().Hosts)}
+		}
+		return hostMsg{gen: gen, backend: connectBackend()}
+	}
+}
+
+func (m *model) applyHost(msg hostMsg) tea.Cmd {
+	if msg.gen != m.hostGen {
+		return nil
+	}
+	m.hostStatus = ""
+	m.host = msg.host
+	m.backend = msg.backend
+	m.selected = 0
+	m.refreshGen++
+	m.refresh()
+	m.pollEvery = m.pollInterval()
+	return pollCmd(m.pollEvery, m.hostGen)
+}
+
+func (m model) backendFor(session screenSession) sessionBackend {
+	if aggregate, ok := m.backend.(*aggregateBackend); ok {
+		if session.host == "" {
+			return aggregate.local
+		}
+		if host, ok := findHost(session.host); ok {
+			return remoteBackend{host: host}
+		}
+	}
+	return m.backend
+}
+
+// createCwd is the directory new sessions start in. Remote sessions get an
+// empty one so they start in the login directory on the host.
+func (m model) createCwd() (string, error) {
+	if m.host != "" && m.host != "all" {
+		return "", nil
+	}
+	return os.Getwd()
+}
+
+func (m model) runsLocally() bool {
+	backend := m.backend
+	if aggregate, ok := backend.(*aggregateBackend); ok {
+		backend = aggregate.local
+	}
+	_, ok := backend.(localBackend)
+	return ok
+}
+
+func (m model) hostHeader() string {
+	if m.host == "all" {
+		up := 1
+		for _, status := range m.hostStatuses {
+			if status == "" {
+				up++
+			}
+		}
+		summary := fmt.Sprintf("%d/%d hosts", up, len(m.hostStatuses)+1)
+		if up < len(m.hostStatuses)+1 {
+			return statusDetachedStyle.Render("✕ "+summary) + fmt.Sprintf(" [%d]", len(m.sessions))
+		}
+		return statusAttachedStyle.Render("● "+summary) + fmt.Sprintf(" [%d]", len(m.sessions))
+	}
+	if m.host == "" {
+		return fmt.Sprintf("cpu %.1f%% ram %.1f%% [%d]", m.cpuUsage, m.memUsage, len(m.sessions))
+	}
+	if m.hostStatus != "" {
+		return statusDetachedStyle.Render("✕ "+m.host) + fmt.Sprintf(" [%d]", len(m.sessions))
+	}
+	return statusAttachedStyle.Render("● "+m.host) + fmt.Sprintf(" [%d]", len(m.sessions))
+}
+
+func (m model) hostPickerView() string {
+	var list strings.Builder
+	list.WriteString(accentStyle.Render("hosts") + "\n\n")
+	current := m.host
+	if current == "" {
+		current = "local"
+	}
+	for i, name := range hostChoices() {
+		label := name
+		if name == current {
+			label += " ●"
+		}
+		if i == m.hostCursor {
+			list.WriteString(selectedStyle.Render(label) + "\n")
+		} else {
+			list.WriteString(label + "\n")
+		}
+	}
+	if m.hostStatus != "" {
+		list.WriteString("\n" + statusDetachedStyle.Render(m.hostStatus) + "\n")
+	}
+	list.WriteString("\n" + mutedTextStyle.Render(footerText([]keyHint{
+		{key: firstKey(m.keys.PaneUp) + firstKey(m.keys.PaneDown), desc: "choose"},
+		{key: firstKey(m.keys.Confirm), desc: "connect"},
+		{key: firstKey(m.keys.Back), desc: "cancel"},
+	})))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, inputStyle.Render(list.String()))
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+type sortMode int
+
+const (
+	sortNone sortMode = iota
+	sortName
+	sortStatus
+	sortNewest
+	sortModeCount
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortName:
+		return "name"
+	case sortStatus:
+		return "status"
+	case sortNewest:
+		return "newest"
+	}
+	return ""
+}
+
+var statusRank = map[string]int{"attached": 0, "detached": 1, "exited": 2}
+
+func (m model) arrange(sessions []screenSession) []screenSession {
+	var arranged []screenSession
+	query := strings.ToLower(m.filter)
+	for _, session := range sessions {
+		if session.foreign && !m.showForeign {
+			continue
+		}
+		if query == "" || strings.Contains(strings.ToLower(session.name+" "+session.command+" "+session.description+" "+session.host), query) {
+			arranged = append(arranged, session)
+		}
+	}
+
+	switch m.sortMode {
+	case sortName:
+		sort.SliceStable(arranged, func(i, j int) bool { return arranged[i].name < arranged[j].name })
+	case sortStatus:
+		sort.SliceStable(arranged, func(i, j int) bool {
+			return statusRank[arranged[i].status] < statusRank[arranged[j].status]
+		})
+	case sortNewest:
+		sort.SliceStable(arranged, func(i, j int) bool { return arranged[i].started.After(arranged[j].started) })
+	}
+
+	if m.host == "all" {
+		order := make(map[string]int)
+		for i, name := range hostChoices() {
+			order[name] = i
+		}
+		order[""] = order["local"]
+		sort.SliceStable(arranged, func(i, j int) bool { return order[arranged[i].host] < order[arranged[j].host] })
+	}
+	return arranged
+}
+
+func (m *model) rearrange() {
+	sessions := m.arrange(m.sessions)
+	if m.selected < len(m.sessions) {
+		current := m.sessions[m.selected]
+		for i, session := range sessions {
+			if session.name == current.name && session.host == current.host {
+				m.selected = i
+			}
+		}
+	}
+	m.sessions = sessions
+}
+
+type sidebarRow struct {
+	text    string
+	session int
+}
+
+func (m model) sidebarRows() []sidebarRow {
+	if m.host != "all" {
+		return m.sessionRows("")
+	}
+	var rows []sidebarRow
+	for _, name := range hostChoices() {
+		if name == "all" {
+			continue
+		}
+		if name == "local" {
+			name = ""
+		}
+		rows = append(rows, sidebarRow{text: m.hostGroupLabel(name), session: -1})
+		rows = append(rows, m.sessionRows(name)...)
+	}
+	return rows
+}
+
+func (m model) sessionRows(host string) []sidebarRow {
+	var rows []sidebarRow
+	for i, session := range m.sessions {
+		if m.host == "all" && session.host != host {
+			continue
+		}
+		sessionDisplay := session.name
+		if session.autostart {
+			sessionDisplay += " ●"
+		}
+		badge := ""
+		if alert, ok := m.alerts[session.name]; ok && session.host == "" {
+			badge = " " + alertBadge(alert.severity)
+		}
+		var text string
+		if m.host == "all" {
+			sessionDisplay = "  " + sessionDisplay
+		}
+		if i == m.selected {
+			text = selectedStyle.Render(sessionDisplay) + badge
+		} else if session.status == "exited" || session.foreign {
+			text = mutedTextStyle.Render(sessionDisplay) + badge
+		} else {
+			text = sessionDisplay + badge
+		}
+		rows = append(rows, sidebarRow{text: text, session: i})
+	}
+	return rows
+}
+
+func (m model) hostGroupLabel(host string) string {
+	if host == "" {
+		return mutedTextStyle.Render("local")
+	}
+	switch status := m.hostStatuses[host]; status {
+	case "":
+		return mutedTextStyle.Render(host)
+	case "connecting":
+		return mutedTextStyle.Render(host + " …")
+	default:
+		return statusDetachedStyle.Render("✕ " + host)
+	}
+}
+
+func (m model) sidebarWindow(l layout) (rows []sidebarRow, start, end int) {
+	rows = m.sidebarRows()
+	listViewportHeight := l.sidebarHeight - 4
+	if len(rows) > listViewportHeight {
+		listViewportHeight -= 2
+	}
+	if listViewportHeight < 1 {
+		listViewportHeight = 1
+	}
+
+	selectedRow := 0
+	for i, row := range rows {
+		if row.session == m.selected {
+			selectedRow = i
+		}
+	}
+
+	end = len(rows)
+	if len(rows) > listViewportHeight {
+		if selectedRow >= listViewportHeight {
+			start = selectedRow - listViewportHeight + 1
+		}
+		end = start + listViewportHeight
+		if end > len(rows) {
+			end = len(rows)
+		}
+	}
+	return rows, start, end
+}
+
+func (m model) sidebarTitle(l layout) string {
+	title := "sessions"
+	if m.filter != "" {
+		title += " /" + m.filter
+	}
+	if m.sortMode != sortNone {
+		title += " ↕" + m.sortMode.String()
+	}
+	return accentStyle.Render(ansi.Truncate(title, l.sidebarWidth-6, "…"))
+}
+
+// sidebarText is the session list cut to the inner size of the sidebar, so
+// long names never wrap and shift the rows under the mouse.
+func (m model) sidebarText(l layout) string {
+	var sidebar strings.Builder
+	sidebar.WriteString(m.sidebarTitle(l) + "\n\n")
+
+	rows, start, end := m.sidebarWindow(l)
+	hasMoreAbove := start > 0
+	hasMoreBelow := end < len(rows)
+
+	if hasMoreAbove {
+		sidebar.WriteString(overflowStyle.Render("... ↑ more above") + "\n")
+	}
+
+	if len(rows) == 0 {
+		if m.lastRefresh.IsZero() && m.refreshErr == nil {
+			sidebar.WriteString(mutedTextStyle.Render("loading…") + "\n")
+		} else if m.filter != "" {
+			sidebar.WriteString(mutedTextStyle.Render("no sessions match filter") + "\n")
+		} else {
+			sidebar.WriteString(mutedTextStyle.Render("no active sessions") + "\n")
+		}
+	} else {
+		for _, row := range rows[start:end] {
+			sidebar.WriteString(ansi.Truncate(row.text, l.sidebarWidth-6, "…") + "\n")
+		}
+	}
+
+	if hasMoreBelow {
+		sidebar.WriteString(overflowStyle.Render("... ↓ more below"))
+	}
+
+	return clipLines(strings.TrimSuffix(sidebar.String(), "\n"), l.sidebarHeight-2)
+}
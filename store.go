@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const storeVersion = 1
+
+type Store struct {
+	Version  int            `json:"version"`
+	Sessions []SessionEntry `json:"sessions"`
+}
+
+func (s *Store) find(name string) *SessionEntry {
+	for i := range s.Sessions {
+		if s.Sessions[i].Name == name {
+			return &s.Sessions[i]
+		}
+	}
+	return nil
+}
+
+func writeFileAtomic(file string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+type storeRecoveredError struct {
+	msg string
+}
+
+func (e storeRecoveredError) Error() string {
+	return e.msg
+}
+
+func isStoreRecovered(err error) bool {
+	_, ok := err.(storeRecoveredError)
+	return ok
+}
+
+func parseStore(data []byte) (Store, error) {
+	store := Store{Version: storeVersion, Sessions: []SessionEntry{}}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return store, err
+	}
+	if store.Version > storeVersion {
+		return store, fmt.Errorf("written by a newer spv (store version %d)", store.Version)
+	}
+	return store, nil
+}
+
+type storeCorruptError struct {
+	err error
+}
+
+func (e storeCorruptError) Error() string {
+	return fmt.Sprintf("%s is corrupt: %v", sessionFile, e.err)
+}
+
+// loadStore only reads sessions.json. A corrupt store is reported but left
+// alone; it is repaired by the next updateStore, which holds the lock.
+func loadStore() (Store, error) {
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Store{Version: storeVersion, Sessions: []SessionEntry{}}, nil
+		}
+		return Store{}, err
+	}
+	store, err := parseStore(data)
+	if err != nil {
+		if store.Version > storeVersion {
+			return Store{}, fmt.Errorf("%s %v", sessionFile, err)
+		}
+		return Store{Version: storeVersion, Sessions: []SessionEntry{}}, storeCorruptError{err}
+	}
+	return store, nil
+}
+
+// loadStoreLocked is loadStore for callers holding the store lock, moving a
+// corrupt store aside and restoring the last good backup.
+func loadStoreLocked() (Store, error) {
+	store, err := loadStore()
+	if corrupt, ok := err.(storeCorruptError); ok {
+		return recoverStore(corrupt.err)
+	}
+	return store, err
+}
+
+// checkStore repairs a corrupt store at startup and reports what was done.
+func checkStore() error {
+	return withFileLock(sessionFile, func() error {
+		_, err := loadStoreLocked()
+		return err
+	})
+}
+
+func recoverStore(parseErr error) (Store, error) {
+	corruptFile := fmt.Sprintf("%s.corrupt-%s", sessionFile, time.Now().Format("20060102-150405"))
+	if err := os.Rename(sessionFile, corruptFile); err != nil {
+		return Store{}, fmt.Errorf("%s is corrupt (%v) and could not be moved aside: %v", sessionFile, parseErr, err)
+	}
+
+	store := Store{Version: storeVersion, Sessions: []SessionEntry{}}
+	source := "an empty store"
+	if data, err := os.ReadFile(sessionFile + ".bak"); err == nil {
+		if backup, err := parseStore(data); err == nil {
+			store = backup
+			source = sessionFile + ".bak"
+		}
+	}
+	if err := saveStore(store); err != nil {
+		return store, err
+	}
+	return store, storeRecoveredError{fmt.Sprintf("%s was corrupt (%v); restored from %s, corrupt copy kept at %s",
+		filepath.Base(sessionFile), parseErr, source, corruptFile)}
+}
+
+func saveStore(store Store) error {
+	store.Version = storeVersion
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	if previous, err := os.ReadFile(sessionFile); err == nil {
+		if _, err := parseStore(previous); err == nil {
+			writeFileAtomic(sessionFile+".bak", previous, 0644)
+		}
+	}
+	return writeFileAtomic(sessionFile, data, 0644)
+}
+
+func updateStore(fn func(store *Store) error) error {
+	return withFileLock(sessionFile, func() error {
+		store, err := loadStoreLocked()
+		if err != nil && !isStoreRecovered(err) {
+			return err
+		}
+		if err := fn(&store); err != nil {
+			return err
+		}
+		return saveStore(store)
+	})
+}
+
+// addSessionEntry adds entry to the store and returns it as stored. Names
+// are checked under the store lock, so two clients can't both add one.
+func addSessionEntry(entry SessionEntry) (SessionEntry, error) {
+	now := time.Now()
+	entry.CreatedAt, entry.StartedAt = now, now
+	err := updateStore(func(store *Store) error {
+		if store.find(entry.Name) != nil {
+			return fmt.Errorf("a session named %s already exists", entry.Name)
+		}
+		// A session renamed away from this name may still be writing its
+		// exit code to the default path, so pick a fresh one if it is taken.
+		for _, other := range store.Sessions {
+			if other.exitFile() == entry.exitFile() {
+				entry.ExitFile = exitCodeFile(fmt.Sprintf("%s.%d", entry.Name, now.UnixNano()))
+			}
+		}
+		store.Sessions = append(store.Sessions, entry)
+		return nil
+	})
+	return entry, err
+}
+
+func removeSessionEntry(name string) error {
+	return updateStore(func(store *Store) error {
+		var updatedEntries []SessionEntry
+		for _, entry := range store.Sessions {
+			if entry.Name != name {
+				updatedEntries = append(updatedEntries, entry)
+			}
+		}
+		store.Sessions = updatedEntries
+		return nil
+	})
+}
+
+func readLegacyEntries(file string) ([]SessionEntry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []SessionEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+	}
+	return entries, nil
+}
+
+func isLegacySessionFile() bool {
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		return false
+	}
+	for _, c := range data {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// backupFile keeps the legacy file as <file>.v0.bak. An existing backup is
+// never replaced, since it may be the only copy of the original data.
+func backupFile(file string) error {
+	if _, err := os.Lstat(file + ".v0.bak"); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return writeFileAtomic(file+".v0.bak", data, 0644)
+}
+
+func migrateStore() error {
+	return withFileLock(sessionFile, migrateStoreLocked)
+}
+
+func migrateStoreLocked() error {
+	_, err := os.Stat(autostartFile)
+	hasLegacyAutostart := err == nil
+	hasLegacySessions := isLegacySessionFile()
+	if !hasLegacySessions && !hasLegacyAutostart {
+		return nil
+	}
+
+	var sessions []SessionEntry
+	if hasLegacySessions {
+		sessions, err = readLegacyEntries(sessionFile)
+	} else {
+		var store Store
+		store, err = loadStoreLocked()
+		sessions = store.Sessions
+		if isStoreRecovered(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	autostartEntries, err := readLegacyEntries(autostartFile)
+	if err != nil {
+		return err
+	}
+
+	store := Store{Version: storeVersion, Sessions: sessions}
+	for _, legacy := range autostartEntries {
+		if entry := store.find(legacy.Name); entry != nil {
+			entry.Autostart = true
+		} else {
+			legacy.Autostart = true
+			store.Sessions = append(store.Sessions, legacy)
+		}
+	}
+
+	if hasLegacySessions {
+		if err := backupFile(sessionFile); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", sessionFile, err)
+		}
+	}
+	if hasLegacyAutostart {
+		if err := backupFile(autostartFile); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", autostartFile, err)
+		}
+	}
+	if err := saveStore(store); err != nil {
+		return err
+	}
+	if hasLegacyAutostart {
+		return os.Remove(autostartFile)
+	}
+	return nil
+}
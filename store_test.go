@@ -0,0 +1,222 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMigrateStore(t *testing.T) {
+	tests := []struct {
+		name          string
+		sessions      string
+		autostart     string
+		backup        string
+		wantSessions  []string
+		wantAutostart []string
+		wantBackups   []string
+	}{
+		{
+			name:         "new install",
+			wantSessions: nil,
+		},
+		{
+			name:          "already migrated",
+			sessions:      `{"version": 1, "sessions": [{"name": "web", "autostart": true}]}`,
+			wantSessions:  []string{"web"},
+			wantAutostart: []string{"web"},
+		},
+		{
+			name:         "legacy sessions",
+			sessions:     `[{"name": "web", "command": "npm start"}, {"name": "db"}]`,
+			wantSessions: []string{"web", "db"},
+			wantBackups:  []string{"sessions.json"},
+		},
+		{
+			name:          "legacy sessions and autostart",
+			sessions:      `[{"name": "web"}]`,
+			autostart:     `[{"name": "web"}, {"name": "worker"}]`,
+			wantSessions:  []string{"web", "worker"},
+			wantAutostart: []string{"web", "worker"},
+			wantBackups:   []string{"sessions.json", "autostart.json"},
+		},
+		{
+			name:          "legacy autostart next to a new store",
+			sessions:      `{"version": 1, "sessions": [{"name": "web"}]}`,
+			autostart:     `[{"name": "web"}]`,
+			wantSessions:  []string{"web"},
+			wantAutostart: []string{"web"},
+			wantBackups:   []string{"autostart.json"},
+		},
+		{
+			name:         "existing backup is kept",
+			sessions:     `[{"name": "web"}]`,
+			backup:       "original",
+			wantSessions: []string{"web"},
+			wantBackups:  []string{"sessions.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setupPaths(t.TempDir()); err != nil {
+				t.Fatal(err)
+			}
+			write := func(file, data string) {
+				if data != "" {
+					if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			write(sessionFile, tt.sessions)
+			write(autostartFile, tt.autostart)
+			write(sessionFile+".v0.bak", tt.backup)
+
+			if err := migrateStore(); err != nil {
+				t.Fatalf("migrateStore() error = %v", err)
+			}
+			store, err := loadStore()
+			if err != nil {
+				t.Fatalf("loadStore() error = %v", err)
+			}
+			var names, autostart []string
+			for _, entry := range store.Sessions {
+				names = append(names, entry.Name)
+				if entry.Autostart {
+					autostart = append(autostart, entry.Name)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantSessions, ",") {
+				t.Errorf("sessions = %v, want %v", names, tt.wantSessions)
+			}
+			if strings.Join(autostart, ",") != strings.Join(tt.wantAutostart, ",") {
+				t.Errorf("autostart = %v, want %v", autostart, tt.wantAutostart)
+			}
+			if _, err := os.Stat(autostartFile); !os.IsNotExist(err) {
+				t.Errorf("autostart.json still exists after migration")
+			}
+			for _, file := range []string{sessionFile, autostartFile} {
+				_, err := os.Stat(file + ".v0.bak")
+				want := false
+				for _, name := range tt.wantBackups {
+					want = want || strings.HasSuffix(file, name)
+				}
+				if want != (err == nil) {
+					t.Errorf("backup of %s exists = %v, want %v", file, err == nil, want)
+				}
+			}
+			if tt.backup != "" {
+				if data, _ := os.ReadFile(sessionFile + ".v0.bak"); string(data) != tt.backup {
+					t.Errorf("existing backup was replaced with %q", data)
+				}
+			}
+		})
+	}
+}
+
+func TestParseStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantNames []string
+		wantErr   string
+	}{
+		{"empty object", `{}`, nil, ""},
+		{"sessions", `{"version": 1, "sessions": [{"name": "a"}, {"name": "b"}]}`, []string{"a", "b"}, ""},
+		{"invalid JSON", `{"version": 1, "sessions": [`, nil, "unexpected end"},
+		{"legacy array", `[{"name": "a"}]`, nil, "cannot unmarshal"},
+		{"newer version", `{"version": 99, "sessions": []}`, nil, "newer spv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := parseStore([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseStore() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStore() error = %v", err)
+			}
+			if store.Version != storeVersion || store.Sessions == nil {
+				t.Errorf("parseStore() = %+v, want version %d and a non-nil session list", store, storeVersion)
+			}
+			var names []string
+			for _, entry := range store.Sessions {
+				names = append(names, entry.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("sessions = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestCorruptStoreRecoveredOnlyUnderLock(t *testing.T) {
+	if err := setupPaths(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sessionFile, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadStore(); err == nil {
+		t.Fatal("loadStore() on a corrupt store returned no error")
+	}
+	if data, _ := os.ReadFile(sessionFile); string(data) != "{not json" {
+		t.Fatalf("loadStore() changed the corrupt store to %q", data)
+	}
+	if err := checkStore(); err == nil {
+		t.Fatal("checkStore() did not report the recovery")
+	}
+	if _, err := loadStore(); err != nil {
+		t.Errorf("loadStore() after recovery error = %v", err)
+	}
+}
+
+func TestAddSessionEntry(t *testing.T) {
+	tests := []struct {
+		name            string
+		existing        string
+		exitClaimed     bool
+		wantErr         string
+		wantDefaultExit bool
+	}{
+		{name: "new name", existing: "db", wantDefaultExit: true},
+		{name: "duplicate name", existing: "web", wantErr: "already exists"},
+		{name: "exit file still claimed by a renamed session", existing: "old", exitClaimed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := setupPaths(t.TempDir()); err != nil {
+				t.Fatal(err)
+			}
+			existing := SessionEntry{Name: tt.existing}
+			if tt.exitClaimed {
+				existing.ExitFile = exitCodeFile("web")
+			}
+			if err := saveStore(Store{Version: storeVersion, Sessions: []SessionEntry{existing}}); err != nil {
+				t.Fatal(err)
+			}
+			entry, err := addSessionEntry(SessionEntry{Name: "web"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("addSessionEntry() error = %v, want %q", err, tt.wantErr)
+				}
+				if store, _ := loadStore(); len(store.Sessions) != 1 {
+					t.Errorf("store has %d sessions after a rejected add, want 1", len(store.Sessions))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("addSessionEntry() error = %v", err)
+			}
+			if (entry.exitFile() == exitCodeFile("web")) != tt.wantDefaultExit {
+				t.Errorf("exit file = %s, want default %v", entry.exitFile(), tt.wantDefaultExit)
+			}
+			if entry.CreatedAt.IsZero() {
+				t.Error("CreatedAt not set")
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var themeDefaults = Theme{
+	Title:          lipgloss.Color("#F1F5F9"),
+	ErrorBg:        lipgloss.Color("#FF0000"),
+	ErrorFg:        lipgloss.Color("#FFFFFF"),
+	LinkGit:        lipgloss.Color("#10B981"),
+	LinkBluesky:    lipgloss.Color("#0EA5E9"),
+	LinkLinkedIn:   lipgloss.Color("#0077B5"),
+	LinkSoundCloud: lipgloss.Color("#FF5500"),
+}
+
+// themeFiles maps the names of themes loaded from themesDir to their files.
+var themeFiles = make(map[string]string)
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+type themeSlot struct {
+	name     string
+	color    *lipgloss.Color
+	required bool
+}
+
+func (t *Theme) slots() []themeSlot {
+	return []themeSlot{
+		{"header_bg", &t.HeaderBg, true},
+		{"panel_bg", &t.PanelBg, true},
+		{"border", &t.Border, true},
+		{"text", &t.Text, true},
+		{"muted_text", &t.MutedText, true},
+		{"accent", &t.Accent, true},
+		{"selected_bg", &t.SelectedBg, true},
+		{"selected_fg", &t.SelectedFg, true},
+		{"status_attached", &t.StatusAttached, true},
+		{"status_detached", &t.StatusDetached, true},
+		{"title", &t.Title, false},
+		{"error_bg", &t.ErrorBg, false},
+		{"error_fg", &t.ErrorFg, false},
+		{"link_git", &t.LinkGit, false},
+		{"link_bluesky", &t.LinkBluesky, false},
+		{"link_linkedin", &t.LinkLinkedIn, false},
+		{"link_soundcloud", &t.LinkSoundCloud, false},
+	}
+}
+
+func (t Theme) withDefaults() Theme {
+	if t.Monochrome {
+		return t
+	}
+	defaults := themeDefaults
+	fallback := defaults.slots()
+	for i, slot := range t.slots() {
+		if *slot.color == "" {
+			*slot.color = *fallback[i].color
+		}
+	}
+	return t
+}
+
+func validColor(value string) bool {
+	if hexColorPattern.MatchString(value) {
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n >= 0 && n <= 255
+}
+
+type themeError struct {
+	name string
+	path string
+	err  error
+}
+
+func (e themeError) Error() string {
+	return fmt.Sprintf("theme %s: %v", e.path, e.err)
+}
+
+func themesDir() string {
+	return filepath.Join(configDir, "themes")
+}
+
+func themeNames() []string {
+	var names []string
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// themeFormats are the file extensions read from themesDir, in the order a
+// name defined by both is resolved.
+var themeFormats = []string{".json", ".toml"}
+
+func decodeThemeFile(data []byte, format string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if format == ".toml" {
+		if _, err := toml.Decode(string(data), &raw); err != nil {
+			return nil, fmt.Errorf("invalid TOML: %v", err)
+		}
+		return raw, nil
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON (expected an object of colors): %v", err)
+	}
+	return raw, nil
+}
+
+// colorValue accepts a color as a string, or an ANSI color as a bare number
+// such as "accent": 203.
+func colorValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), true
+	case float64:
+		if v == math.Trunc(v) {
+			return strconv.Itoa(int(v)), true
+		}
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	}
+	return "", false
+}
+
+func parseThemeFile(data []byte, format string, builtin map[string]Theme) (Theme, error) {
+	raw, err := decodeThemeFile(data, format)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	var theme Theme
+	if value, ok := raw["extends"]; ok {
+		base, isString := value.(string)
+		if !isString {
+			return Theme{}, fmt.Errorf("extends must be the name of a built-in theme, got %v", value)
+		}
+		parent, ok := builtin[base]
+		if !ok {
+			var names []string
+			for name := range builtin {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return Theme{}, fmt.Errorf("extends unknown theme %q, built-in themes are %s", base, strings.Join(names, ", "))
+		}
+		delete(raw, "extends")
+		if parent.Monochrome {
+			if len(raw) > 0 {
+				return Theme{}, fmt.Errorf("%s has no colors to override, extend a color theme instead", base)
+			}
+			return parent, nil
+		}
+		theme = parent
+	}
+
+	slots := make(map[string]themeSlot)
+	var slotNames []string
+	for _, slot := range theme.slots() {
+		slots[slot.name] = slot
+		slotNames = append(slotNames, slot.name)
+	}
+	var keys []string
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		slot, ok := slots[key]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown color slot %q, valid slots are extends, %s", key, strings.Join(slotNames, ", "))
+		}
+		value, ok := colorValue(raw[key])
+		if !ok || !validColor(value) {
+			return Theme{}, fmt.Errorf("%s: %v is not a color, use \"#RRGGBB\", \"#RGB\" or an ANSI color number 0-255", key, raw[key])
+		}
+		*slot.color = lipgloss.Color(value)
+	}
+
+	var missing []string
+	for _, slot := range theme.slots() {
+		if slot.required && *slot.color == "" {
+			missing = append(missing, slot.name)
+		}
+	}
+	if len(missing) > 0 {
+		return Theme{}, fmt.Errorf("missing %s (or set \"extends\" to a built-in theme to inherit them)", strings.Join(missing, ", "))
+	}
+	return theme, nil
+}
+
+// loadUserThemes adds every valid <name>.json and <name>.toml in themesDir
+// to themes, replacing a built-in theme of the same name. Broken files are
+// skipped.
+func loadUserThemes() []themeError {
+	dir := themesDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []themeError{{path: dir, err: err}}
+	}
+
+	builtin := make(map[string]Theme)
+	for name, theme := range themes {
+		builtin[name] = theme
+	}
+
+	var errs []themeError
+	for _, format := range themeFormats {
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != format {
+				continue
+			}
+			name := strings.TrimSuffix(entry.Name(), format)
+			path := filepath.Join(dir, entry.Name())
+			if other, ok := themeFiles[name]; ok {
+				errs = append(errs, themeError{name: name, path: path, err: fmt.Errorf("theme %s is already defined by %s", name, other)})
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err == nil {
+				var theme Theme
+				if theme, err = parseThemeFile(data, format, builtin); err == nil {
+					themes[name] = theme
+					themeFiles[name] = path
+					continue
+				}
+			}
+			errs = append(errs, themeError{name: name, path: path, err: err})
+		}
+	}
+	return errs
+}
+
+func listThemes(w io.Writer, errs []themeError) {
+	current := loadTheme()
+	for _, name := range themeNames() {
+		marker := "  "
+		if name == current {
+			marker = "* "
+		}
+		source := "built-in"
+		if path, ok := themeFiles[name]; ok {
+			source = path
+		}
+		fmt.Fprintf(w, "%s%-12s %s\n", marker, name, source)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, err := range errs {
+		fmt.Fprintf(w, "skipped %v\n", err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestParseThemeFile(t *testing.T) {
+	builtin := map[string]Theme{"slate": themes["slate"]}
+	full := `"header_bg": "#000", "panel_bg": "#000", "border": "#111", "text": "#fff", "muted_text": "#888",
+		"selected_bg": "#00f", "selected_fg": "#fff", "status_attached": "#0f0", "status_detached": "#ff0"`
+	tests := []struct {
+		name       string
+		data       string
+		format     string
+		wantAccent lipgloss.Color
+		wantBorder lipgloss.Color
+		wantErr    string
+	}{
+		{"json", `{` + full + `, "accent": "#FF00AA"}`, ".json", "#FF00AA", "#111", ""},
+		{"json extends", `{"extends": "slate", "accent": "#f0a"}`, ".json", "#f0a", themes["slate"].Border, ""},
+		{"json ANSI number", `{"extends": "slate", "accent": 203}`, ".json", "203", themes["slate"].Border, ""},
+		{"json ANSI string", `{"extends": "slate", "accent": " 42 "}`, ".json", "42", themes["slate"].Border, ""},
+		{"toml", "extends = \"slate\"\naccent = \"#112233\"\nborder = 8\n", ".toml", "#112233", "8", ""},
+		{"fractional number", `{"extends": "slate", "accent": 203.5}`, ".json", "", "", "is not a color"},
+		{"out of range", `{"extends": "slate", "accent": 256}`, ".json", "", "", "is not a color"},
+		{"color name", `{"extends": "slate", "accent": "red"}`, ".json", "", "", "is not a color"},
+		{"unknown slot", `{"extends": "slate", "acent": "#fff"}`, ".json", "", "", `unknown color slot "acent"`},
+		{"missing slots", `{"accent": "#fff", "text": "#000"}`, ".json", "", "", "missing header_bg, panel_bg, border, muted_text"},
+		{"unknown extends", `{"extends": "dracula"}`, ".json", "", "", `extends unknown theme "dracula", built-in themes are slate`},
+		{"extends not a string", `{"extends": 1}`, ".json", "", "", "extends must be the name"},
+		{"invalid json", `["#fff"]`, ".json", "", "", "invalid JSON"},
+		{"invalid toml", "accent = \n", ".toml", "", "", "invalid TOML"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, err := parseThemeFile([]byte(tt.data), tt.format, builtin)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseThemeFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseThemeFile() error = %v", err)
+			}
+			if theme.Accent != tt.wantAccent || theme.Border != tt.wantBorder {
+				t.Errorf("accent, border = %q, %q, want %q, %q", theme.Accent, theme.Border, tt.wantAccent, tt.wantBorder)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// updateThemePicker previews the highlighted theme as the cursor moves; the
+// theme is only saved on enter, and esc restores the one that was active.
+func (m model) updateThemePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	names := themeNames()
+	switch {
+	case key.Matches(msg, m.keys.PaneUp):
+		if m.themeCursor > 0 {
+			m.themeCursor--
+		}
+	case key.Matches(msg, m.keys.PaneDown):
+		if m.themeCursor < len(names)-1 {
+			m.themeCursor++
+		}
+	case key.Matches(msg, m.keys.Confirm):
+		if m.themeCursor < len(names) {
+			name := names[m.themeCursor]
+			applyTheme(name)
+			if err := saveTheme(name); err != nil {
+				m.notify(noticeError, fmt.Sprintf("Failed to save theme: %v", err))
+			}
+		}
+		m.state = listView
+		return m, nil
+	case key.Matches(msg, m.keys.Back):
+		applyTheme(m.themeBefore)
+		m.state = listView
+		return m, nil
+	}
+	if m.themeCursor < len(names) {
+		applyTheme(names[m.themeCursor])
+	}
+	return m, nil
+}
+
+func (m model) themePickerView() string {
+	names := themeNames()
+	rows := m.height - 10
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if m.themeCursor >= rows {
+		start = m.themeCursor - rows + 1
+	}
+
+	var list strings.Builder
+	list.WriteString(accentStyle.Render("themes") + "\n\n")
+	for i := start; i < len(names) && i < start+rows; i++ {
+		name := names[i]
+		label := name
+		if name == m.themeBefore {
+			label += " ●"
+		}
+		if i == m.themeCursor {
+			list.WriteString(selectedStyle.Render(label) + "\n")
+		} else {
+			list.WriteString(label + "\n")
+		}
+	}
+	list.WriteString("\n" + mutedTextStyle.Render(footerText([]keyHint{
+		{key: firstKey(m.keys.PaneUp) + firstKey(m.keys.PaneDown), desc: "preview"},
+		{key: firstKey(m.keys.Confirm), desc: "save"},
+		{key: firstKey(m.keys.Back), desc: "cancel"},
+	})))
+	return inputStyle.Render(list.String())
+}
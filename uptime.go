@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func exitCodeFile(name string) string {
+	return filepath.Join(stateDir, "exit", name)
+}
+
+// exitFile is where the session's wrapper writes the command's exit code.
+// It keeps the path of the name the session was started under, because a
+// running wrapper can't be told about a rename.
+func (e SessionEntry) exitFile() string {
+	if e.ExitFile != "" {
+		return e.ExitFile
+	}
+	return exitCodeFile(e.Name)
+}
+
+func readExitCode(file string) (string, time.Time) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", time.Time{}
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return "", time.Time{}
+	}
+	return strings.TrimSpace(string(data)), info.ModTime()
+}
+
+func screenStartTime(id string) time.Time {
+	pid, err := strconv.ParseInt(id, 10, 32)
+	if err != nil {
+		return time.Time{}
+	}
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return time.Time{}
+	}
+	created, err := p.CreateTime()
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(created)
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm %ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
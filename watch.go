@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const defaultWatchCooldown = time.Minute
+
+type WatchRule struct {
+	Pattern  string `json:"pattern"`
+	Severity string `json:"severity,omitempty"`
+	Cooldown string `json:"cooldown,omitempty"`
+}
+
+type sessionAlert struct {
+	session  string
+	severity string
+	pattern  string
+	line     string
+	at       time.Time
+}
+
+type logWatcher struct {
+	mu        sync.Mutex
+	offsets   map[string]int64
+	lastFired map[string]time.Time
+	patterns  map[string]*regexp.Regexp
+	badRules  map[string]bool
+}
+
+func newLogWatcher() *logWatcher {
+	return &logWatcher{
+		offsets:   make(map[string]int64),
+		lastFired: make(map[string]time.Time),
+		patterns:  make(map[string]*regexp.Regexp),
+		badRules:  make(map[string]bool),
+	}
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "error":
+		return 2
+	case "warn":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (w *logWatcher) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := w.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	w.patterns[pattern] = re
+	return re, nil
+}
+
+func (w *logWatcher) readNewLines(name string) []string {
+	f, err := os.Open(sessionLogFile(name))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil
+	}
+
+	offset, seen := w.offsets[name]
+	if !seen {
+		w.offsets[name] = info.Size()
+		return nil
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return nil
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil
+	}
+
+	var lines []string
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		offset += int64(len(line))
+		lines = append(lines, strings.TrimSpace(cleanLogOutput([]byte(line))))
+	}
+	w.offsets[name] = offset
+	return lines
+}
+
+func (w *logWatcher) scan(entries []SessionEntry) []sessionAlert {
+	// A scan that timed out may still be reading; skip rather than race it.
+	if !w.mu.TryLock() {
+		return nil
+	}
+	defer w.mu.Unlock()
+	var alerts []sessionAlert
+	now := time.Now()
+	for _, entry := range entries {
+		if len(entry.Watch) == 0 || !loggingEnabled(entry) {
+			continue
+		}
+		lines := w.readNewLines(entry.Name)
+		for _, rule := range entry.Watch {
+			key := entry.Name + "\x00" + rule.Pattern
+			re, err := w.compile(rule.Pattern)
+			if err != nil {
+				if !w.badRules[key] {
+					w.badRules[key] = true
+					alerts = append(alerts, sessionAlert{
+						session:  entry.Name,
+						severity: "error",
+						pattern:  rule.Pattern,
+						line:     fmt.Sprintf("invalid watch pattern: %v", err),
+						at:       now,
+					})
+				}
+				continue
+			}
+			cooldown := defaultWatchCooldown
+			if rule.Cooldown != "" {
+				if d, err := time.ParseDuration(rule.Cooldown); err == nil {
+					cooldown = d
+				}
+			}
+			for _, line := range lines {
+				if !re.MatchString(line) {
+					continue
+				}
+				if last, ok := w.lastFired[key]; ok && now.Sub(last) < cooldown {
+					break
+				}
+				w.lastFired[key] = now
+				severity := rule.Severity
+				if severity == "" {
+					severity = "warn"
+				}
+				alerts = append(alerts, sessionAlert{
+					session:  entry.Name,
+					severity: severity,
+					pattern:  rule.Pattern,
+					line:     line,
+					at:       now,
+				})
+				break
+			}
+		}
+	}
+	return alerts
+}
+
+func alertBadge(severity string) string {
+	switch severity {
+	case "error":
+		return errorTextStyle.Copy().Padding(0).Render("!")
+	case "warn":
+		return statusDetachedStyle.Render("!")
+	default:
+		return accentStyle.Render("i")
+	}
+}
+
+type alertsMsg struct {
+	alerts []sessionAlert
+}
+
+func (w *logWatcher) scanCmd() tea.Cmd {
+	return timeoutCmd(func() tea.Msg {
+		store, err := loadStore()
+		if err != nil {
+			return alertsMsg{}
+		}
+		return alertsMsg{alerts: w.scan(store.Sessions)}
+	}, alertsMsg{})
+}